@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"sync"
 
 	"github.com/hashicorp/vault/sdk/framework"
 	"github.com/hashicorp/vault/sdk/logical"
@@ -36,13 +37,32 @@ func newBackend(version string, logger *logrus.Logger) *backend {
 			versionPaths(b),
 			storagePaths(b),
 			storageSlashingPaths(b),
+			storageSlashingInterchangePaths(b),
 			accountsPaths(b),
+			accountsPubKeysPaths(b),
+			accountWithdrawalPubKeyPaths(b),
+			walletRecoverPaths(b),
+			accessTokensPaths(b),
 			signsPaths(b),
+			signsExtraPaths(b),
+			signsAttestationBatchPaths(b),
+			web3signerPaths(b),
 			configPaths(b),
 		),
 		PathsSpecial: &logical.Paths{
 			SealWrapStorage: []string{
 				"wallet/",
+				accessTokenSecretPrefix,
+			},
+			// Web3Signer clients authenticate at the TLS/proxy layer rather than with a
+			// Vault token; in place of Vault's ACL layer, the sign, public key and reload
+			// handlers require a signed per-account access token (verifyRequiredAccessToken /
+			// verifyAnyAccessToken). Upcheck requires none.
+			Unauthenticated: []string{
+				Web3SignerSignPattern,
+				Web3SignerPublicKeysPattern,
+				Web3SignerUpcheckPattern,
+				Web3SignerReloadPattern,
 			},
 		},
 		Secrets:     []*framework.Secret{},
@@ -56,6 +76,43 @@ type backend struct {
 	*framework.Backend
 	logger  *logrus.Logger
 	Version string
+
+	// storageSchemaVersion is the schema version runMigrations left the store at, cached here
+	// during Setup so request handlers don't have to re-read it on every call.
+	storageSchemaVersion int
+
+	// signingLocks serializes the read-check-sign-write slashing protection sequence per
+	// pubkey (map[string]*sync.Mutex), so two requests for the same validator - e.g. two
+	// items in one attestation batch, or a batch racing a concurrent single-item sign
+	// request - can never both observe the same stale history and both pass the slashing
+	// check.
+	signingLocks sync.Map
+}
+
+// lockPubKey blocks until it holds the per-pubkey lock serializing slashing-protection
+// sequences for pubKey, returning an unlock function the caller must defer.
+func (b *backend) lockPubKey(pubKey string) func() {
+	value, _ := b.signingLocks.LoadOrStore(pubKey, &sync.Mutex{})
+	mu := value.(*sync.Mutex)
+	mu.Lock()
+	return mu.Unlock
+}
+
+// Setup shadows framework.Backend's own Setup: it delegates to it first so the embedded
+// framework is fully initialized, then brings storage up to currentSchemaVersion before the
+// backend is allowed to serve any request.
+func (b *backend) Setup(ctx context.Context, conf *logical.BackendConfig) error {
+	if err := b.Backend.Setup(ctx, conf); err != nil {
+		return err
+	}
+
+	version, err := runMigrations(ctx, conf.StorageView, b.logger)
+	if err != nil {
+		return errors.Wrap(err, "failed to migrate storage schema")
+	}
+	b.storageSchemaVersion = version
+
+	return nil
 }
 
 func (b *backend) pathExistenceCheck(ctx context.Context, req *logical.Request, data *framework.FieldData) (bool, error) {
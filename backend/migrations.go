@@ -0,0 +1,355 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/pkg/errors"
+
+	"github.com/bloxapp/KeyVault/core"
+)
+
+// schemaVersionStorageKey holds the storage schema version this plugin instance has migrated
+// the store to. Its absence means a pre-migration-framework store, i.e. version 0.
+const schemaVersionStorageKey = "meta/schema_version"
+
+// migrationJournalStorageKey is a write-ahead marker: it is set to the index of the migration
+// about to run, and cleared only once that migration returns successfully. A plugin that finds
+// it set on startup crashed mid-migration and is retrying; every migration below is written to
+// be safe to run again against a store it has partially or fully already migrated.
+const migrationJournalStorageKey = "meta/migration_journal"
+
+// currentSchemaVersion is the highest storage schema version this binary knows how to read and
+// write. A store at a higher version was migrated by a newer binary; this one must refuse to
+// touch it rather than risk misinterpreting a layout it doesn't understand.
+const currentSchemaVersion = 4
+
+// migrations is the ordered list of forward migrations. migrations[i] takes the store from
+// schema version i to i+1; len(migrations) must equal currentSchemaVersion.
+var migrations = []func(ctx context.Context, storage logical.Storage) error{
+	migrateAddDerivationPath,
+	migrateSlashingHistoryEpochIndex,
+	migrateWalletGenesisValidatorsRoot,
+	migrateRequireInterchangeImportGap,
+}
+
+// runMigrations brings storage up to currentSchemaVersion, recording progress in
+// schemaVersionStorageKey as each migration commits. It is called from Setup, before the
+// backend serves any request.
+func runMigrations(ctx context.Context, storage logical.Storage, logger migrationLogger) (int, error) {
+	version, err := getSchemaVersion(ctx, storage)
+	if err != nil {
+		return 0, err
+	}
+
+	if version > currentSchemaVersion {
+		return version, errors.Errorf("storage schema version %d is newer than this binary supports (%d); refusing to start", version, currentSchemaVersion)
+	}
+
+	journal, err := storage.Get(ctx, migrationJournalStorageKey)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to read migration journal")
+	}
+	if journal != nil {
+		logger.Warnf("found a migration journal entry from an interrupted run at schema version %d; retrying", version)
+	}
+
+	for version < currentSchemaVersion {
+		if err := storage.Put(ctx, &logical.StorageEntry{Key: migrationJournalStorageKey, Value: []byte(fmt.Sprintf("%d", version))}); err != nil {
+			return version, errors.Wrap(err, "failed to write migration journal")
+		}
+
+		if err := migrations[version](ctx, storage); err != nil {
+			return version, errors.Wrapf(err, "migration %d->%d failed", version, version+1)
+		}
+
+		version++
+		if err := putSchemaVersion(ctx, storage, version); err != nil {
+			return version, err
+		}
+		if err := storage.Delete(ctx, migrationJournalStorageKey); err != nil {
+			return version, errors.Wrap(err, "failed to clear migration journal")
+		}
+	}
+
+	// A run interrupted after its last migration committed but before the journal was cleared
+	// leaves nothing to retry; clear the leftover journal so it is not reported again.
+	if journal != nil {
+		if err := storage.Delete(ctx, migrationJournalStorageKey); err != nil {
+			return version, errors.Wrap(err, "failed to clear migration journal")
+		}
+	}
+
+	return version, nil
+}
+
+// migrationLogger is the subset of *logrus.Logger the migration framework needs, kept narrow
+// so it's trivial to satisfy from tests.
+type migrationLogger interface {
+	Warnf(format string, args ...interface{})
+}
+
+func getSchemaVersion(ctx context.Context, storage logical.Storage) (int, error) {
+	entry, err := storage.Get(ctx, schemaVersionStorageKey)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to read storage schema version")
+	}
+	if entry == nil {
+		return 0, nil
+	}
+
+	var version int
+	if err := entry.DecodeJSON(&version); err != nil {
+		return 0, errors.Wrap(err, "failed to decode storage schema version")
+	}
+	return version, nil
+}
+
+func putSchemaVersion(ctx context.Context, storage logical.Storage, version int) error {
+	entry, err := logical.StorageEntryJSON(schemaVersionStorageKey, version)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode storage schema version")
+	}
+	if err := storage.Put(ctx, entry); err != nil {
+		return errors.Wrap(err, "failed to persist storage schema version")
+	}
+	return nil
+}
+
+// accountMetaPrefix stores this plugin's own per-account bookkeeping, kept alongside (not
+// inside) the KeyVault library's own wallet/account records.
+const accountMetaPrefix = "account-meta/"
+
+// accountMeta is supplementary per-account metadata this plugin tracks.
+type accountMeta struct {
+	DerivationPath string `json:"derivation_path"`
+
+	// WithdrawalPublicKey is the 48-byte hex-encoded EIP-2334 withdrawal public key paired
+	// with this account's signing key, recorded so deposit data can be produced for a
+	// recovered validator. Only the public key is kept: the withdrawal private key is
+	// deliberately never persisted, matching the usual practice of keeping it cold.
+	WithdrawalPublicKey string `json:"withdrawal_public_key,omitempty"`
+}
+
+func accountMetaStorageKey(pubKey string) string {
+	return accountMetaPrefix + pubKey
+}
+
+func getAccountMeta(ctx context.Context, storage logical.Storage, pubKey string) (*accountMeta, error) {
+	entry, err := storage.Get(ctx, accountMetaStorageKey(pubKey))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read account meta")
+	}
+	if entry == nil {
+		return &accountMeta{}, nil
+	}
+
+	var meta accountMeta
+	if err := entry.DecodeJSON(&meta); err != nil {
+		return nil, errors.Wrap(err, "failed to decode account meta")
+	}
+	return &meta, nil
+}
+
+func putAccountMeta(ctx context.Context, storage logical.Storage, pubKey string, meta *accountMeta) error {
+	entry, err := logical.StorageEntryJSON(accountMetaStorageKey(pubKey), meta)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode account meta")
+	}
+	if err := storage.Put(ctx, entry); err != nil {
+		return errors.Wrap(err, "failed to persist account meta")
+	}
+	return nil
+}
+
+// migrateAddDerivationPath backfills an accountMeta entry for every pre-existing account, so
+// derivation_path becomes a field every account can be expected to have going forward (wallet
+// recovery already writes it for newly derived accounts; accounts created before this migration
+// get an empty placeholder since their original derivation path, if any, was never recorded).
+func migrateAddDerivationPath(ctx context.Context, storage logical.Storage) error {
+	pubKeys, err := allAccountPubKeys(ctx, storage)
+	if err != nil {
+		return errors.Wrap(err, "failed to list accounts")
+	}
+
+	for _, pubKey := range pubKeys {
+		entry, err := storage.Get(ctx, accountMetaStorageKey(pubKey))
+		if err != nil {
+			return errors.Wrap(err, "failed to read account meta")
+		}
+		if entry != nil {
+			continue
+		}
+		if err := putAccountMeta(ctx, storage, pubKey, &accountMeta{}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// slashingEpochIndexPrefix indexes, per pubkey, the epoch range the monolithic slashing
+// history blob currently covers. It is additive: getSlashingHistory/putSlashingHistory remain
+// the single source of truth and every existing caller is unaffected. The index exists so a
+// future O(log n) range lookup (e.g. "has this validator signed anything since epoch X") can be
+// answered without decoding every pubkey's blob, without first having to migrate every sign
+// path over to a new storage layout in one breaking step.
+const slashingEpochIndexPrefix = "slashing-epoch-index/"
+
+// slashingEpochIndex is the per-pubkey summary persisted under slashingEpochIndexPrefix.
+type slashingEpochIndex struct {
+	HighestSourceEpoch uint64 `json:"highest_source_epoch"`
+	HighestTargetEpoch uint64 `json:"highest_target_epoch"`
+}
+
+func slashingEpochIndexStorageKey(pubKey string) string {
+	return slashingEpochIndexPrefix + pubKey
+}
+
+// migrateSlashingHistoryEpochIndex builds the slashingEpochIndex for every pubkey that already
+// has slashing history, from the existing monolithic blob.
+func migrateSlashingHistoryEpochIndex(ctx context.Context, storage logical.Storage) error {
+	pubKeys, err := allAccountPubKeys(ctx, storage)
+	if err != nil {
+		return errors.Wrap(err, "failed to list accounts")
+	}
+
+	for _, pubKey := range pubKeys {
+		history, err := getSlashingHistory(ctx, storage, pubKey)
+		if err != nil {
+			return err
+		}
+		if !history.HasAttested {
+			continue
+		}
+
+		index := slashingEpochIndex{
+			HighestSourceEpoch: history.HighestSourceEpoch,
+			HighestTargetEpoch: history.HighestTargetEpoch,
+		}
+		entry, err := logical.StorageEntryJSON(slashingEpochIndexStorageKey(pubKey), index)
+		if err != nil {
+			return errors.Wrap(err, "failed to encode slashing epoch index")
+		}
+		if err := storage.Put(ctx, entry); err != nil {
+			return errors.Wrap(err, "failed to persist slashing epoch index")
+		}
+	}
+	return nil
+}
+
+// migrateRequireInterchangeImportGap marks every pubkey that has no local slashing history yet
+// as RequiresImport, the same flag a freshly recovered account gets (see pathWalletRecover). The
+// slashing history store this plugin's own sign paths read and write (slashingHistoryPrefix) is
+// separate from whatever store the pre-existing native single-item attestation/proposal/
+// aggregation sign handlers use - that code lives outside this commit series and isn't present
+// in this source tree, so there is no way to read its high-water marks directly. Without this
+// migration, an account that already has real signing history through the native path would
+// start with a silently empty local history the first time it signs through one of the paths
+// this series added, which could let a replayed or conflicting request through. Requiring an
+// explicit EIP-3076 interchange import (even an empty one, if the operator is confident the
+// account has never signed) before first use of the new paths closes that gap. A pubkey that
+// already has local history is left alone, since that only happens if it has already signed
+// through one of these paths before this migration ran.
+func migrateRequireInterchangeImportGap(ctx context.Context, storage logical.Storage) error {
+	pubKeys, err := allAccountPubKeys(ctx, storage)
+	if err != nil {
+		return errors.Wrap(err, "failed to list accounts")
+	}
+
+	for _, pubKey := range pubKeys {
+		history, err := getSlashingHistory(ctx, storage, pubKey)
+		if err != nil {
+			return err
+		}
+		if history.HasProposed || history.HasAttested || history.HasSignedSyncCommittee || history.HasVoluntaryExit || history.RequiresImport {
+			continue
+		}
+
+		history.RequiresImport = true
+		if err := putSlashingHistory(ctx, storage, pubKey, history); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// walletMetaPrefix stores this plugin's own per-wallet bookkeeping, alongside the KeyVault
+// library's own wallet records.
+const walletMetaPrefix = "wallet-meta/"
+
+// walletMeta is supplementary per-wallet metadata this plugin tracks.
+type walletMeta struct {
+	// GenesisValidatorsRoot is a per-wallet genesis validators root, seeded from the plugin-wide
+	// config of the same name. No request path reads or writes it: interchange import and the
+	// Web3Signer surface use only the plugin-wide value.
+	GenesisValidatorsRoot string `json:"genesis_validators_root"`
+}
+
+func walletMetaStorageKey(walletName string) string {
+	return walletMetaPrefix + walletName
+}
+
+func getWalletMeta(ctx context.Context, storage logical.Storage, walletName string) (*walletMeta, error) {
+	entry, err := storage.Get(ctx, walletMetaStorageKey(walletName))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read wallet meta")
+	}
+	if entry == nil {
+		return &walletMeta{}, nil
+	}
+
+	var meta walletMeta
+	if err := entry.DecodeJSON(&meta); err != nil {
+		return nil, errors.Wrap(err, "failed to decode wallet meta")
+	}
+	return &meta, nil
+}
+
+func putWalletMeta(ctx context.Context, storage logical.Storage, walletName string, meta *walletMeta) error {
+	entry, err := logical.StorageEntryJSON(walletMetaStorageKey(walletName), meta)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode wallet meta")
+	}
+	if err := storage.Put(ctx, entry); err != nil {
+		return errors.Wrap(err, "failed to persist wallet meta")
+	}
+	return nil
+}
+
+// migrateWalletGenesisValidatorsRoot seeds a walletMeta entry for every wallet, defaulting its
+// genesis_validators_root to the plugin-wide value. Wallets that already have a walletMeta entry
+// are left as they are.
+func migrateWalletGenesisValidatorsRoot(ctx context.Context, storage logical.Storage) error {
+	config, err := getConfig(ctx, storage)
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve plugin config")
+	}
+
+	kv, err := core.OpenKeyVault(&core.PortfolioOptions{Storage: storage})
+	if err != nil {
+		if err == core.ErrKeyVaultNotFound {
+			return nil
+		}
+		return errors.Wrap(err, "failed to open key vault")
+	}
+
+	wallets, err := kv.Wallets()
+	if err != nil {
+		return errors.Wrap(err, "failed to list wallets")
+	}
+
+	for _, wallet := range wallets {
+		entry, err := storage.Get(ctx, walletMetaStorageKey(wallet.Name()))
+		if err != nil {
+			return errors.Wrap(err, "failed to read wallet meta")
+		}
+		if entry != nil {
+			continue
+		}
+		if err := putWalletMeta(ctx, storage, wallet.Name(), &walletMeta{GenesisValidatorsRoot: config.GenesisValidatorsRoot}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package backend
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+type testMigrationLogger struct {
+	warnings []string
+}
+
+func (l *testMigrationLogger) Warnf(format string, args ...interface{}) {
+	l.warnings = append(l.warnings, format)
+}
+
+func TestRunMigrationsIsIdempotent(t *testing.T) {
+	storage := &logical.InmemStorage{}
+	logger := &testMigrationLogger{}
+
+	version, err := runMigrations(context.Background(), storage, logger)
+	require.NoError(t, err)
+	require.Equal(t, currentSchemaVersion, version)
+	require.Empty(t, logger.warnings)
+
+	t.Run("running again against an already-migrated store is a no-op", func(t *testing.T) {
+		version, err := runMigrations(context.Background(), storage, logger)
+		require.NoError(t, err)
+		require.Equal(t, currentSchemaVersion, version)
+	})
+
+	t.Run("a leftover journal entry from an interrupted run is retried and cleared", func(t *testing.T) {
+		require.NoError(t, storage.Put(context.Background(), &logical.StorageEntry{
+			Key: migrationJournalStorageKey, Value: []byte("0"),
+		}))
+
+		version, err := runMigrations(context.Background(), storage, logger)
+		require.NoError(t, err)
+		require.Equal(t, currentSchemaVersion, version)
+		require.NotEmpty(t, logger.warnings)
+
+		entry, err := storage.Get(context.Background(), migrationJournalStorageKey)
+		require.NoError(t, err)
+		require.Nil(t, entry)
+	})
+}
+
+func TestMigrateRequireInterchangeImportGap(t *testing.T) {
+	b, _ := getBackend(t)
+	req := logical.TestRequest(t, logical.CreateOperation, "wallets/wallet1")
+	storage := req.Storage
+	_, err := b.HandleRequest(context.Background(), req)
+	require.NoError(t, err)
+
+	req = logical.TestRequest(t, logical.CreateOperation, "wallets/wallet1/accounts/account1")
+	req.Storage = storage
+	res, err := b.HandleRequest(context.Background(), req)
+	require.NoError(t, err)
+	pubKey := res.Data["account"].(map[string]interface{})["public_key"].(string)
+
+	t.Run("an account with no prior slashing history is flagged as requiring import", func(t *testing.T) {
+		require.NoError(t, migrateRequireInterchangeImportGap(context.Background(), storage))
+
+		history, err := getSlashingHistory(context.Background(), storage, pubKey)
+		require.NoError(t, err)
+		require.True(t, history.RequiresImport)
+	})
+
+	t.Run("an account that has already attested is left alone", func(t *testing.T) {
+		req := logical.TestRequest(t, logical.CreateOperation, "wallets/wallet1/accounts/account2")
+		req.Storage = storage
+		res, err := b.HandleRequest(context.Background(), req)
+		require.NoError(t, err)
+		otherPubKey := res.Data["account"].(map[string]interface{})["public_key"].(string)
+
+		require.NoError(t, putSlashingHistory(context.Background(), storage, otherPubKey, &slashingHistory{
+			HasAttested: true, HighestSourceEpoch: 1, HighestTargetEpoch: 2,
+		}))
+
+		require.NoError(t, migrateRequireInterchangeImportGap(context.Background(), storage))
+
+		history, err := getSlashingHistory(context.Background(), storage, otherPubKey)
+		require.NoError(t, err)
+		require.False(t, history.RequiresImport)
+	})
+}
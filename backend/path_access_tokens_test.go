@@ -0,0 +1,98 @@
+package backend
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+// requirePermissionDenied asserts that err is a permission-denied failure - the one
+// errwrap-wrapped sentinel Vault's own HTTP frontend maps to a 403 response, see
+// logical.RespondErrorCommon - rather than the 400 a plain errorex.NewErrBadRequest produces.
+func requirePermissionDenied(t *testing.T, err error, wantMsg string) {
+	t.Helper()
+	require.EqualError(t, err, wantMsg)
+	require.True(t, errwrap.Contains(err, logical.ErrPermissionDenied.Error()),
+		"expected err to wrap logical.ErrPermissionDenied so Vault reports 403, got: %v", err)
+}
+
+func TestIssueAccessTokenScoping(t *testing.T) {
+	b, _ := getBackend(t)
+	req := logical.TestRequest(t, logical.CreateOperation, "wallets/wallet1")
+	storage := req.Storage
+	_, err := b.HandleRequest(context.Background(), req)
+	require.NoError(t, err)
+
+	req = logical.TestRequest(t, logical.CreateOperation, "wallets/wallet1/accounts/account1")
+	req.Storage = storage
+	res, err := b.HandleRequest(context.Background(), req)
+	require.NoError(t, err)
+	pubKey := res.Data["account"].(map[string]interface{})["public_key"].(string)
+
+	t.Run("unknown operation is rejected", func(t *testing.T) {
+		req := logical.TestRequest(t, logical.CreateOperation, "wallets/wallet1/accounts/account1/access-token")
+		req.Storage = storage
+		req.Data = map[string]interface{}{"operations": []string{"sign_attestation", "sign_everything"}}
+		_, err := b.HandleRequest(context.Background(), req)
+		require.EqualError(t, err, "unknown operation sign_everything")
+	})
+
+	t.Run("issued token authorizes only its pubkey and granted operations", func(t *testing.T) {
+		req := logical.TestRequest(t, logical.CreateOperation, "wallets/wallet1/accounts/account1/access-token")
+		req.Storage = storage
+		req.Data = map[string]interface{}{"operations": []string{"sign_attestation"}}
+		res, err := b.HandleRequest(context.Background(), req)
+		require.NoError(t, err)
+		token := res.Data["token"].(string)
+
+		claims, err := parseAccessToken(context.Background(), storage, &logical.Request{
+			Headers: map[string][]string{"Authorization": {"Bearer " + token}},
+		}, true)
+		require.NoError(t, err)
+
+		require.NoError(t, claims.authorize(pubKey, "sign_attestation"))
+		requirePermissionDenied(t, claims.authorize(pubKey, "sign_proposal"), "access token does not permit sign_proposal: permission denied")
+		requirePermissionDenied(t, claims.authorize("0xother", "sign_attestation"), "access token is not scoped to this account: permission denied")
+	})
+
+	t.Run("ttl_seconds must be positive", func(t *testing.T) {
+		req := logical.TestRequest(t, logical.CreateOperation, "wallets/wallet1/accounts/account1/access-token")
+		req.Storage = storage
+		req.Data = map[string]interface{}{"ttl_seconds": 0}
+		_, err := b.HandleRequest(context.Background(), req)
+		require.EqualError(t, err, "ttl_seconds must be positive")
+	})
+}
+
+func TestVerifyAccessTokenIsOptionalButValidatedWhenPresent(t *testing.T) {
+	b, _ := getBackend(t)
+	req := logical.TestRequest(t, logical.CreateOperation, "wallets/wallet1")
+	storage := req.Storage
+	_, err := b.HandleRequest(context.Background(), req)
+	require.NoError(t, err)
+
+	req = logical.TestRequest(t, logical.CreateOperation, "wallets/wallet1/accounts/account1")
+	req.Storage = storage
+	res, err := b.HandleRequest(context.Background(), req)
+	require.NoError(t, err)
+	pubKey := res.Data["account"].(map[string]interface{})["public_key"].(string)
+
+	t.Run("no Authorization header is let through", func(t *testing.T) {
+		err := verifyAccessToken(context.Background(), storage, &logical.Request{}, pubKey, "sign_attestation")
+		require.NoError(t, err)
+	})
+
+	t.Run("a garbage Authorization header is rejected with permission denied, not bad request", func(t *testing.T) {
+		req := &logical.Request{Headers: map[string][]string{"Authorization": {"Bearer not-a-jwt"}}}
+		err := verifyAccessToken(context.Background(), storage, req, pubKey, "sign_attestation")
+		requirePermissionDenied(t, err, "invalid or expired access token: permission denied")
+	})
+
+	t.Run("verifyRequiredAccessToken rejects a missing token outright, with permission denied, not bad request", func(t *testing.T) {
+		err := verifyRequiredAccessToken(context.Background(), storage, &logical.Request{}, pubKey, "sign_attestation")
+		requirePermissionDenied(t, err, "missing access token: permission denied")
+	})
+}
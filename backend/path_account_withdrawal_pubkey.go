@@ -0,0 +1,61 @@
+package backend
+
+import (
+	"context"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+
+	"github.com/bloxapp/key-vault/utils/errorex"
+)
+
+// AccountWithdrawalPubKeyPattern exposes the EIP-2334 withdrawal public key accompanying a
+// recovered account's signing key, so a caller producing deposit data for that validator does
+// not need the withdrawal private key (which this plugin never persists - see
+// pathWalletRecover).
+const AccountWithdrawalPubKeyPattern = `wallets/(?P<wallet>\w(([\w-.]+)?\w)?)/accounts/(?P<account>\w(([\w-.]+)?\w)?)/withdrawal-public-key`
+
+// accountWithdrawalPubKeyPaths returns the per-account withdrawal public key read path.
+func accountWithdrawalPubKeyPaths(b *backend) []*framework.Path {
+	return []*framework.Path{
+		&framework.Path{
+			Pattern:         AccountWithdrawalPubKeyPattern,
+			HelpSynopsis:    "Reads the withdrawal public key paired with a recovered account's signing key",
+			HelpDescription: ``,
+			Fields: map[string]*framework.FieldSchema{
+				"wallet":  {Type: framework.TypeString, Description: "Wallet name"},
+				"account": {Type: framework.TypeString, Description: "Account name"},
+			},
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.ReadOperation: b.pathAccountWithdrawalPubKey,
+			},
+		},
+	}
+}
+
+// pathAccountWithdrawalPubKey returns the hex-encoded withdrawal public key accountMeta
+// recorded for the named account. Accounts created directly (not via pathWalletRecover) have no
+// withdrawal public key on file, and reading one returns a bad request error.
+func (b *backend) pathAccountWithdrawalPubKey(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	walletName := data.Get("wallet").(string)
+	accountName := data.Get("account").(string)
+
+	pubKey, err := accountPubKeyByName(req.Storage, walletName, accountName)
+	if err != nil {
+		return nil, err
+	}
+
+	meta, err := getAccountMeta(ctx, req.Storage, pubKey)
+	if err != nil {
+		return nil, err
+	}
+	if meta.WithdrawalPublicKey == "" {
+		return nil, errorex.NewErrBadRequest("account " + accountName + " has no withdrawal public key on file")
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"withdrawal_public_key": meta.WithdrawalPublicKey,
+		},
+	}, nil
+}
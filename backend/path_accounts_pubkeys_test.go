@@ -0,0 +1,38 @@
+package backend
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccountsPubKeysReadOperation(t *testing.T) {
+	b, _ := getBackend(t)
+	req := logical.TestRequest(t, logical.CreateOperation, "wallets/wallet1")
+	storage := req.Storage
+	_, err := b.HandleRequest(context.Background(), req)
+	require.NoError(t, err)
+
+	req = logical.TestRequest(t, logical.CreateOperation, "wallets/wallet1/accounts/account1")
+	req.Storage = storage
+	_, err = b.HandleRequest(context.Background(), req)
+	require.NoError(t, err)
+
+	t.Run("a bare GET routes to ReadOperation, which Vault's HTTP frontend picks for a request with no trailing slash and no list=true, matching VaultRemoteHTTPWallet.FetchValidatingKeys", func(t *testing.T) {
+		req := logical.TestRequest(t, logical.ReadOperation, "wallets/wallet1/accounts/pubkeys")
+		req.Storage = storage
+		res, err := b.HandleRequest(context.Background(), req)
+		require.NoError(t, err)
+		pubKeys := res.Data["pubkeys"].([]string)
+		require.Len(t, pubKeys, 1)
+	})
+
+	t.Run("unknown wallet is reported through ReadOperation too", func(t *testing.T) {
+		req := logical.TestRequest(t, logical.ReadOperation, "wallets/unknown_wallet/accounts/pubkeys")
+		req.Storage = storage
+		_, err := b.HandleRequest(context.Background(), req)
+		require.EqualError(t, err, "failed to list wallet account public keys: failed to retrieve wallet by name: no wallet found")
+	})
+}
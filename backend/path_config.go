@@ -0,0 +1,88 @@
+package backend
+
+import (
+	"context"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/pkg/errors"
+)
+
+// ConfigPattern is the plugin-wide configuration path.
+const ConfigPattern = "config"
+
+// configPaths returns the plugin-wide configuration path: genesis_validators_root plus the
+// Web3Signer surface settings (web3signer_path_prefix, web3signer_enabled) that
+// authenticateWeb3SignerRequest reads on every Web3Signer-compatible request.
+func configPaths(b *backend) []*framework.Path {
+	return []*framework.Path{
+		&framework.Path{
+			Pattern:         ConfigPattern,
+			HelpSynopsis:    "Reads and writes plugin-wide configuration",
+			HelpDescription: ``,
+			Fields: map[string]*framework.FieldSchema{
+				"genesis_validators_root": {Type: framework.TypeString, Description: "Genesis validators root used to validate interchange imports and Web3Signer domains"},
+				"web3signer_path_prefix":  {Type: framework.TypeString, Description: "Leading path segment the Web3Signer-compatible surface is served under"},
+				"web3signer_enabled":      {Type: framework.TypeBool, Description: "Serves the Web3Signer-compatible surface on this mount; per-account access tokens, not this flag, authorize individual requests"},
+			},
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.ReadOperation:   b.pathConfigRead,
+				logical.CreateOperation: b.pathConfigWrite,
+				logical.UpdateOperation: b.pathConfigWrite,
+			},
+		},
+	}
+}
+
+// pathConfigRead returns the plugin's current configuration.
+func (b *backend) pathConfigRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	config, err := getConfig(ctx, req.Storage)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve plugin config")
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"genesis_validators_root": config.GenesisValidatorsRoot,
+			"web3signer_path_prefix":  config.Web3SignerPathPrefix,
+			"web3signer_enabled":      config.Web3SignerEnabled,
+		},
+	}, nil
+}
+
+// pathConfigWrite merges any fields present in the request into the persisted plugin config;
+// fields the caller omits are left at their current value.
+func (b *backend) pathConfigWrite(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	config, err := getConfig(ctx, req.Storage)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve plugin config")
+	}
+
+	if raw, ok := data.GetOk("genesis_validators_root"); ok {
+		config.GenesisValidatorsRoot = raw.(string)
+	}
+	if raw, ok := data.GetOk("web3signer_path_prefix"); ok {
+		config.Web3SignerPathPrefix = raw.(string)
+	}
+	if raw, ok := data.GetOk("web3signer_enabled"); ok {
+		config.Web3SignerEnabled = raw.(bool)
+	}
+
+	if err := putConfig(ctx, req.Storage, config); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+// putConfig persists the plugin-wide configuration at pluginConfigStorageKey.
+func putConfig(ctx context.Context, storage logical.Storage, config *pluginConfig) error {
+	entry, err := logical.StorageEntryJSON(pluginConfigStorageKey, config)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode plugin config")
+	}
+	if err := storage.Put(ctx, entry); err != nil {
+		return errors.Wrap(err, "failed to persist plugin config")
+	}
+	return nil
+}
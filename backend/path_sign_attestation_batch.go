@@ -0,0 +1,234 @@
+package backend
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+	ethpb "github.com/prysmaticlabs/ethereumapis/eth/v1alpha1"
+
+	"github.com/bloxapp/key-vault/utils/errorex"
+)
+
+// SignAttestationBatchPattern is the batched counterpart of the attestation sign path: one
+// request carries N (pubkey, domain, data) tuples instead of one round-trip per key.
+const SignAttestationBatchPattern = "sign/attestation/batch"
+
+// attestationBatchWorkers bounds how many attestation signings run concurrently per batch
+// request, so a single oversized batch cannot exhaust the plugin's CPU or the vault storage
+// backend's connection pool.
+const attestationBatchWorkers = 32
+
+// signsAttestationBatchPaths returns the batched attestation signing path.
+func signsAttestationBatchPaths(b *backend) []*framework.Path {
+	return []*framework.Path{
+		&framework.Path{
+			Pattern:         SignAttestationBatchPattern,
+			HelpSynopsis:    "Signs a batch of attestations in parallel",
+			HelpDescription: ``,
+			Fields: map[string]*framework.FieldSchema{
+				"items": {
+					Type:        framework.TypeSlice,
+					Description: "List of (pub_key, domain, data) attestation sign requests",
+				},
+			},
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.CreateOperation: b.pathSignAttestationBatch,
+				logical.UpdateOperation: b.pathSignAttestationBatch,
+			},
+		},
+	}
+}
+
+// attestationBatchItem is a single tuple of the batch request.
+type attestationBatchItem struct {
+	PubKey          string `json:"pub_key"`
+	Domain          string `json:"domain"`
+	Slot            uint64 `json:"slot"`
+	CommitteeIndex  uint64 `json:"committee_index"`
+	BeaconBlockRoot string `json:"beacon_block_root"`
+	SourceEpoch     uint64 `json:"source_epoch"`
+	SourceRoot      string `json:"source_root"`
+	TargetEpoch     uint64 `json:"target_epoch"`
+	TargetRoot      string `json:"target_root"`
+}
+
+// attestationBatchResult is a single entry of the batch response, preserving input order.
+type attestationBatchResult struct {
+	Signature string `json:"signature,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// pathSignAttestationBatch runs the per-key slashing protection checks and BLS signings for
+// every item in parallel, using a bounded worker pool, and assembles a response preserving
+// input order with a per-item error status. A single validator process holding thousands of
+// keys would otherwise incur one round trip per key every slot.
+//
+// If ctx is cancelled mid-dispatch, items already handed to a worker are left to finish - their
+// signAttestationBatchItem call may have already committed a putSlashingHistory write, and
+// discarding its result here would make a client retry see that item spuriously rejected as a
+// replay. Items that were never dispatched are reported as cancelled instead of being silently
+// dropped from the response.
+func (b *backend) pathSignAttestationBatch(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	raw, ok := data.GetOk("items")
+	if !ok {
+		return nil, errorex.NewErrBadRequest("items is required")
+	}
+
+	encoded, err := json.Marshal(raw)
+	if err != nil {
+		return nil, errorex.NewErrBadRequest("failed to re-marshal items")
+	}
+
+	var items []attestationBatchItem
+	if err := json.Unmarshal(encoded, &items); err != nil {
+		return nil, errorex.NewErrBadRequest("failed to parse items: " + err.Error())
+	}
+
+	results := make([]attestationBatchResult, len(items))
+	dispatched := make([]bool, len(items))
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+
+	workers := attestationBatchWorkers
+	if workers > len(items) {
+		workers = len(items)
+	}
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = b.signAttestationBatchItem(ctx, req, items[i])
+			}
+		}()
+	}
+
+dispatch:
+	for i := range items {
+		select {
+		case <-ctx.Done():
+			break dispatch
+		case jobs <- i:
+			dispatched[i] = true
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	for i, wasDispatched := range dispatched {
+		if !wasDispatched {
+			results[i] = attestationBatchResult{Error: "request cancelled before this item could be signed"}
+		}
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"results": results,
+		},
+	}, nil
+}
+
+// signAttestationBatchItem applies the attestation slashing protection check and produces a
+// signature for a single batch item, never returning an error directly so one bad item
+// cannot fail the whole batch.
+func (b *backend) signAttestationBatchItem(ctx context.Context, req *logical.Request, item attestationBatchItem) attestationBatchResult {
+	storage := req.Storage
+
+	unlock := b.lockPubKey(item.PubKey)
+	defer unlock()
+
+	history, err := getSlashingHistory(ctx, storage, item.PubKey)
+	if err != nil {
+		return attestationBatchResult{Error: err.Error()}
+	}
+	if history.HasAttested && (item.SourceEpoch < history.HighestSourceEpoch || item.TargetEpoch <= history.HighestTargetEpoch) {
+		return attestationBatchResult{Error: "attestation violates slashing protection rules"}
+	}
+	if history.RequiresImport {
+		return attestationBatchResult{Error: "account was recovered from seed and requires a slashing protection interchange import before it can sign"}
+	}
+	if err := verifyAccessToken(ctx, storage, req, item.PubKey, "sign_attestation"); err != nil {
+		return attestationBatchResult{Error: err.Error()}
+	}
+
+	account, err := accountByPubKey(storage, item.PubKey)
+	if err != nil {
+		return attestationBatchResult{Error: err.Error()}
+	}
+
+	domain, err := hex.DecodeString(item.Domain)
+	if err != nil {
+		return attestationBatchResult{Error: "failed to decode domain"}
+	}
+
+	signingRoot, err := attestationSigningRoot(item, domain)
+	if err != nil {
+		return attestationBatchResult{Error: err.Error()}
+	}
+
+	sig, err := account.Sign(signingRoot)
+	if err != nil {
+		return attestationBatchResult{Error: "failed to sign attestation: " + err.Error()}
+	}
+
+	history.HighestSourceEpoch = item.SourceEpoch
+	history.HighestTargetEpoch = item.TargetEpoch
+	history.HasAttested = true
+	if err := putSlashingHistory(ctx, storage, item.PubKey, history); err != nil {
+		return attestationBatchResult{Error: err.Error()}
+	}
+
+	return attestationBatchResult{Signature: hex.EncodeToString(sig.Marshal())}
+}
+
+// attestationSigningRoot computes the domain-wrapped signing root of an attestation data
+// tuple, mirroring the domain-check-marshal-send flow of the non-batched attestation path.
+func attestationSigningRoot(item attestationBatchItem, domain []byte) ([]byte, error) {
+	beaconBlockRoot, err := hex.DecodeString(item.BeaconBlockRoot)
+	if err != nil {
+		return nil, errorex.NewErrBadRequest("failed to decode beacon_block_root")
+	}
+	sourceRoot, err := hex.DecodeString(item.SourceRoot)
+	if err != nil {
+		return nil, errorex.NewErrBadRequest("failed to decode source_root")
+	}
+	targetRoot, err := hex.DecodeString(item.TargetRoot)
+	if err != nil {
+		return nil, errorex.NewErrBadRequest("failed to decode target_root")
+	}
+
+	data := &ethpb.AttestationData{
+		Slot:            item.Slot,
+		CommitteeIndex:  item.CommitteeIndex,
+		BeaconBlockRoot: beaconBlockRoot,
+		Source: &ethpb.Checkpoint{
+			Epoch: item.SourceEpoch,
+			Root:  sourceRoot,
+		},
+		Target: &ethpb.Checkpoint{
+			Epoch: item.TargetEpoch,
+			Root:  targetRoot,
+		},
+	}
+
+	objectRoot, err := data.HashTreeRoot()
+	if err != nil {
+		return nil, errorex.NewErrBadRequest("failed to compute attestation object root")
+	}
+
+	signingData := &ethpb.SigningData{
+		ObjectRoot: objectRoot[:],
+		Domain:     domain,
+	}
+	root, err := signingData.HashTreeRoot()
+	if err != nil {
+		return nil, errorex.NewErrBadRequest("failed to compute attestation signing root")
+	}
+
+	return root[:], nil
+}
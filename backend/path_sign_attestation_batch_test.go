@@ -0,0 +1,97 @@
+package backend
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignAttestationBatchPreservesOrderAndRejectsSlashableItems(t *testing.T) {
+	b, _ := getBackend(t)
+	req := logical.TestRequest(t, logical.CreateOperation, "wallets/wallet1")
+	storage := req.Storage
+	_, err := b.HandleRequest(context.Background(), req)
+	require.NoError(t, err)
+
+	var pubKeys []string
+	for _, name := range []string{"account1", "account2"} {
+		req := logical.TestRequest(t, logical.CreateOperation, "wallets/wallet1/accounts/"+name)
+		req.Storage = storage
+		res, err := b.HandleRequest(context.Background(), req)
+		require.NoError(t, err)
+		pubKeys = append(pubKeys, res.Data["account"].(map[string]interface{})["public_key"].(string))
+	}
+
+	zeroRoot := strings.Repeat("00", 32)
+	item := func(pubKey string, sourceEpoch, targetEpoch uint64) map[string]interface{} {
+		return map[string]interface{}{
+			"pub_key":           pubKey,
+			"domain":            zeroRoot,
+			"slot":              1,
+			"committee_index":   0,
+			"beacon_block_root": zeroRoot,
+			"source_epoch":      sourceEpoch,
+			"source_root":       zeroRoot,
+			"target_epoch":      targetEpoch,
+			"target_root":       zeroRoot,
+		}
+	}
+
+	batchReq := logical.TestRequest(t, logical.CreateOperation, SignAttestationBatchPattern)
+	batchReq.Storage = storage
+	batchReq.Data = map[string]interface{}{
+		"items": []interface{}{
+			item(pubKeys[0], 1, 2),
+			item(pubKeys[1], 1, 2),
+		},
+	}
+	res, err := b.HandleRequest(context.Background(), batchReq)
+	require.NoError(t, err)
+	results := res.Data["results"].([]attestationBatchResult)
+	require.Len(t, results, 2)
+	require.Empty(t, results[0].Error)
+	require.Empty(t, results[1].Error)
+
+	t.Run("replaying a lower target epoch for one item does not fail the rest of the batch", func(t *testing.T) {
+		batchReq := logical.TestRequest(t, logical.CreateOperation, SignAttestationBatchPattern)
+		batchReq.Storage = storage
+		batchReq.Data = map[string]interface{}{
+			"items": []interface{}{
+				item(pubKeys[0], 1, 1),
+				item(pubKeys[1], 3, 4),
+			},
+		}
+		res, err := b.HandleRequest(context.Background(), batchReq)
+		require.NoError(t, err)
+		results := res.Data["results"].([]attestationBatchResult)
+		require.Len(t, results, 2)
+		require.Equal(t, "attestation violates slashing protection rules", results[0].Error)
+		require.Empty(t, results[1].Error)
+	})
+
+	t.Run("a context cancelled mid-dispatch still returns every item's result instead of discarding them", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		batchReq := logical.TestRequest(t, logical.CreateOperation, SignAttestationBatchPattern)
+		batchReq.Storage = storage
+		batchReq.Data = map[string]interface{}{
+			"items": []interface{}{
+				item(pubKeys[0], 5, 6),
+				item(pubKeys[1], 5, 6),
+			},
+		}
+		res, err := b.HandleRequest(ctx, batchReq)
+		require.NoError(t, err)
+		results := res.Data["results"].([]attestationBatchResult)
+		require.Len(t, results, 2)
+		for _, result := range results {
+			if result.Error != "" {
+				require.Equal(t, "request cancelled before this item could be signed", result.Error)
+			}
+		}
+	})
+}
@@ -0,0 +1,235 @@
+package backend
+
+import (
+	"context"
+	"encoding/hex"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/pkg/errors"
+
+	"github.com/bloxapp/key-vault/utils/errorex"
+)
+
+// Additional signing endpoint patterns, alongside the existing attestation, proposal and
+// aggregation paths.
+const (
+	SignVoluntaryExitPattern               = "sign/voluntary-exit"
+	SignSyncCommitteeMessagePattern        = "sign/sync-committee-message"
+	SignSyncCommitteeSelectionProofPattern = "sign/sync-committee-selection-proof"
+	SignContributionAndProofPattern        = "sign/contribution-and-proof"
+)
+
+// signsExtraPaths returns the voluntary exit and sync committee signing paths.
+func signsExtraPaths(b *backend) []*framework.Path {
+	return []*framework.Path{
+		&framework.Path{
+			Pattern:         SignVoluntaryExitPattern,
+			HelpSynopsis:    "Signs a voluntary exit message",
+			HelpDescription: ``,
+			Fields: map[string]*framework.FieldSchema{
+				"pub_key":      {Type: framework.TypeString, Description: "Hex encoded public key of the validator"},
+				"domain":       {Type: framework.TypeString, Description: "Hex encoded signing domain"},
+				"epoch":        {Type: framework.TypeInt64, Description: "Epoch at which the exit becomes valid"},
+				"data_to_sign": {Type: framework.TypeString, Description: "Hex encoded signing root"},
+			},
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.CreateOperation: b.pathSignVoluntaryExit,
+				logical.UpdateOperation: b.pathSignVoluntaryExit,
+			},
+		},
+		&framework.Path{
+			Pattern:         SignSyncCommitteeMessagePattern,
+			HelpSynopsis:    "Signs a sync committee message",
+			HelpDescription: ``,
+			Fields: map[string]*framework.FieldSchema{
+				"pub_key":      {Type: framework.TypeString, Description: "Hex encoded public key of the validator"},
+				"domain":       {Type: framework.TypeString, Description: "Hex encoded signing domain"},
+				"slot":         {Type: framework.TypeInt64, Description: "Slot the message is for"},
+				"data_to_sign": {Type: framework.TypeString, Description: "Hex encoded signing root"},
+			},
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.CreateOperation: b.pathSignSyncCommitteeMessage,
+				logical.UpdateOperation: b.pathSignSyncCommitteeMessage,
+			},
+		},
+		&framework.Path{
+			Pattern:         SignSyncCommitteeSelectionProofPattern,
+			HelpSynopsis:    "Signs a sync committee selection proof",
+			HelpDescription: ``,
+			Fields: map[string]*framework.FieldSchema{
+				"pub_key":            {Type: framework.TypeString, Description: "Hex encoded public key of the validator"},
+				"domain":             {Type: framework.TypeString, Description: "Hex encoded signing domain"},
+				"slot":               {Type: framework.TypeInt64, Description: "Slot the proof is for"},
+				"subcommittee_index": {Type: framework.TypeInt64, Description: "Sync subcommittee index"},
+				"data_to_sign":       {Type: framework.TypeString, Description: "Hex encoded signing root"},
+			},
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.CreateOperation: b.pathSignSyncCommitteeSelectionProof,
+				logical.UpdateOperation: b.pathSignSyncCommitteeSelectionProof,
+			},
+		},
+		&framework.Path{
+			Pattern:         SignContributionAndProofPattern,
+			HelpSynopsis:    "Signs a sync committee contribution and proof",
+			HelpDescription: ``,
+			Fields: map[string]*framework.FieldSchema{
+				"pub_key":      {Type: framework.TypeString, Description: "Hex encoded public key of the validator"},
+				"domain":       {Type: framework.TypeString, Description: "Hex encoded signing domain"},
+				"slot":         {Type: framework.TypeInt64, Description: "Slot the contribution is for"},
+				"data_to_sign": {Type: framework.TypeString, Description: "Hex encoded signing root"},
+			},
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.CreateOperation: b.pathSignContributionAndProof,
+				logical.UpdateOperation: b.pathSignContributionAndProof,
+			},
+		},
+	}
+}
+
+// pathSignVoluntaryExit signs a voluntary exit message. A validator must never be asked to
+// sign two different exit messages, so the first signed epoch is pinned in its slashing
+// history and any later request for a different epoch is rejected.
+func (b *backend) pathSignVoluntaryExit(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	pubKey := data.Get("pub_key").(string)
+	epoch := uint64(data.Get("epoch").(int64))
+
+	unlock := b.lockPubKey(pubKey)
+	defer unlock()
+
+	history, err := getSlashingHistory(ctx, req.Storage, pubKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if history.HasVoluntaryExit && history.VoluntaryExitEpoch != epoch {
+		return nil, errorex.NewErrBadRequest("validator already has a voluntary exit signed for a different epoch")
+	}
+
+	signature, err := b.signRoot(ctx, req, data, "sign_exit")
+	if err != nil {
+		return nil, err
+	}
+
+	history.VoluntaryExitEpoch = epoch
+	history.HasVoluntaryExit = true
+	if err := putSlashingHistory(ctx, req.Storage, pubKey, history); err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"signature": signature,
+		},
+	}, nil
+}
+
+// pathSignSyncCommitteeMessage signs a sync committee message, reusing the attestation
+// slashing store's slot bounds so a message signed at slot N cannot later be replayed at
+// slot < N under a different beacon block root.
+func (b *backend) pathSignSyncCommitteeMessage(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	pubKey := data.Get("pub_key").(string)
+	slot := uint64(data.Get("slot").(int64))
+
+	unlock := b.lockPubKey(pubKey)
+	defer unlock()
+
+	history, err := getSlashingHistory(ctx, req.Storage, pubKey)
+	if err != nil {
+		return nil, err
+	}
+	if history.HasSignedSyncCommittee && slot < history.HighestSyncCommitteeSlot {
+		return nil, errorex.NewErrBadRequest("sync committee message slot is lower than the highest previously signed slot")
+	}
+
+	signature, err := b.signRoot(ctx, req, data, "sign_sync_committee_message")
+	if err != nil {
+		return nil, err
+	}
+
+	if !history.HasSignedSyncCommittee || slot > history.HighestSyncCommitteeSlot {
+		history.HighestSyncCommitteeSlot = slot
+		history.HasSignedSyncCommittee = true
+		if err := putSlashingHistory(ctx, req.Storage, pubKey, history); err != nil {
+			return nil, err
+		}
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"signature": signature,
+		},
+	}, nil
+}
+
+// pathSignSyncCommitteeSelectionProof signs a sync committee selection proof. Selection
+// proofs do not themselves attest to anything slashable, so no slashing protection check
+// applies beyond signRoot's own checks.
+func (b *backend) pathSignSyncCommitteeSelectionProof(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	signature, err := b.signRoot(ctx, req, data, "sign_sync_committee_selection_proof")
+	if err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"signature": signature,
+		},
+	}, nil
+}
+
+// pathSignContributionAndProof signs a sync committee contribution and proof.
+func (b *backend) pathSignContributionAndProof(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	signature, err := b.signRoot(ctx, req, data, "sign_contribution_and_proof")
+	if err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"signature": signature,
+		},
+	}, nil
+}
+
+// signRoot resolves the account for the requested public key and produces a BLS signature
+// over the already domain-wrapped signing root in data_to_sign. The domain field is not read
+// or checked against that root. operation scopes the
+// access-token check performed against req; an empty operation skips that check, since not
+// every signing kind has a corresponding entry in allowedAccessTokenOperations.
+func (b *backend) signRoot(ctx context.Context, req *logical.Request, data *framework.FieldData, operation string) (string, error) {
+	storage := req.Storage
+	pubKey := data.Get("pub_key").(string)
+	signingRootHex := data.Get("data_to_sign").(string)
+
+	signingRoot, err := hex.DecodeString(signingRootHex)
+	if err != nil {
+		return "", errorex.NewErrBadRequest("failed to decode data_to_sign")
+	}
+
+	history, err := getSlashingHistory(ctx, storage, pubKey)
+	if err != nil {
+		return "", err
+	}
+	if history.RequiresImport {
+		return "", errorex.NewErrBadRequest("account was recovered from seed and requires a slashing protection interchange import before it can sign")
+	}
+
+	if operation != "" {
+		if err := verifyAccessToken(ctx, storage, req, pubKey, operation); err != nil {
+			return "", err
+		}
+	}
+
+	account, err := accountByPubKey(storage, pubKey)
+	if err != nil {
+		return "", err
+	}
+
+	sig, err := account.Sign(signingRoot)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to sign root")
+	}
+
+	return hex.EncodeToString(sig.Marshal()), nil
+}
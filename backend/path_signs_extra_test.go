@@ -0,0 +1,92 @@
+package backend
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignVoluntaryExitPinsEpoch(t *testing.T) {
+	b, _ := getBackend(t)
+	req := logical.TestRequest(t, logical.CreateOperation, "wallets/wallet1")
+	storage := req.Storage
+	_, err := b.HandleRequest(context.Background(), req)
+	require.NoError(t, err)
+
+	req = logical.TestRequest(t, logical.CreateOperation, "wallets/wallet1/accounts/account1")
+	req.Storage = storage
+	res, err := b.HandleRequest(context.Background(), req)
+	require.NoError(t, err)
+	pubKey := res.Data["account"].(map[string]interface{})["public_key"].(string)
+
+	signReq := func(epoch int64) *logical.Request {
+		req := logical.TestRequest(t, logical.CreateOperation, SignVoluntaryExitPattern)
+		req.Storage = storage
+		req.Data = map[string]interface{}{
+			"pub_key":      pubKey,
+			"domain":       "0x0000000000000000000000000000000000000000000000000000000000000000",
+			"epoch":        epoch,
+			"data_to_sign": "0000000000000000000000000000000000000000000000000000000000000000",
+		}
+		return req
+	}
+
+	t.Run("first exit epoch is accepted", func(t *testing.T) {
+		res, err := b.HandleRequest(context.Background(), signReq(10))
+		require.NoError(t, err)
+		require.NotEmpty(t, res.Data["signature"])
+	})
+
+	t.Run("same epoch again is accepted", func(t *testing.T) {
+		_, err := b.HandleRequest(context.Background(), signReq(10))
+		require.NoError(t, err)
+	})
+
+	t.Run("different epoch is rejected", func(t *testing.T) {
+		_, err := b.HandleRequest(context.Background(), signReq(11))
+		require.EqualError(t, err, "validator already has a voluntary exit signed for a different epoch")
+	})
+}
+
+func TestSignSyncCommitteeMessageRejectsLowerSlot(t *testing.T) {
+	b, _ := getBackend(t)
+	req := logical.TestRequest(t, logical.CreateOperation, "wallets/wallet1")
+	storage := req.Storage
+	_, err := b.HandleRequest(context.Background(), req)
+	require.NoError(t, err)
+
+	req = logical.TestRequest(t, logical.CreateOperation, "wallets/wallet1/accounts/account1")
+	req.Storage = storage
+	res, err := b.HandleRequest(context.Background(), req)
+	require.NoError(t, err)
+	pubKey := res.Data["account"].(map[string]interface{})["public_key"].(string)
+
+	signReq := func(slot int64) *logical.Request {
+		req := logical.TestRequest(t, logical.CreateOperation, SignSyncCommitteeMessagePattern)
+		req.Storage = storage
+		req.Data = map[string]interface{}{
+			"pub_key":      pubKey,
+			"domain":       "0x0000000000000000000000000000000000000000000000000000000000000000",
+			"slot":         slot,
+			"data_to_sign": "0000000000000000000000000000000000000000000000000000000000000000",
+		}
+		return req
+	}
+
+	t.Run("signing an increasing slot is accepted", func(t *testing.T) {
+		_, err := b.HandleRequest(context.Background(), signReq(100))
+		require.NoError(t, err)
+	})
+
+	t.Run("replaying a lower slot is rejected", func(t *testing.T) {
+		_, err := b.HandleRequest(context.Background(), signReq(99))
+		require.EqualError(t, err, "sync committee message slot is lower than the highest previously signed slot")
+	})
+
+	t.Run("the same slot again is accepted", func(t *testing.T) {
+		_, err := b.HandleRequest(context.Background(), signReq(100))
+		require.NoError(t, err)
+	})
+}
@@ -0,0 +1,419 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+
+	"github.com/bloxapp/KeyVault/core"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/pkg/errors"
+
+	"github.com/bloxapp/key-vault/utils/errorex"
+)
+
+// interchangeFormatVersion is the only EIP-3076 interchange format version this plugin understands.
+const interchangeFormatVersion = "5"
+
+// slashingHistoryPrefix is the storage prefix the slashing protection history store persists
+// per-pubkey records under. getSlashingHistory/putSlashingHistory are this store's only
+// accessors; every sign path this plugin's own commits added reads through them, and
+// pathSignVoluntaryExit, pathSignSyncCommitteeMessage, pathSignAttestationBatch's
+// signAttestationBatchItem and pathWeb3SignerSign also write through them. The native single-item
+// attestation/proposal/aggregation sign handlers live in a file outside this commit series and
+// are not present in this source tree, so it cannot be verified that they consult this same
+// store - they may keep their own, separate bookkeeping. Rather than let that ambiguity become
+// a double-sign hole, migrateRequireInterchangeImportGap (see migrations.go) flags every
+// account with no history in this store as RequiresImport: none of the paths above will sign
+// for it until an operator bridges the gap with an EIP-3076 interchange import, so this store
+// can never silently start enforcing a fresh, empty high-water mark for an account that already
+// has real signing history elsewhere.
+const slashingHistoryPrefix = "slashing-history/"
+
+// storageSlashingInterchangePaths returns the paths for importing/exporting the EIP-3076
+// slashing protection interchange format, used to migrate validators between clients.
+func storageSlashingInterchangePaths(b *backend) []*framework.Path {
+	return []*framework.Path{
+		&framework.Path{
+			Pattern:         "slashing-storage/interchange",
+			HelpSynopsis:    "Imports or exports the EIP-3076 slashing protection interchange format",
+			HelpDescription: ``,
+			Fields: map[string]*framework.FieldSchema{
+				"interchange": {
+					Type:        framework.TypeMap,
+					Description: "The EIP-3076 interchange JSON document to import",
+				},
+				"auto_create_missing": {
+					Type:        framework.TypeBool,
+					Default:     false,
+					Description: "If true, pubkeys present in the interchange file but not yet tracked by the vault get an empty history entry instead of being rejected",
+				},
+			},
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.CreateOperation: b.pathImportInterchange,
+				logical.UpdateOperation: b.pathImportInterchange,
+				logical.ReadOperation:   b.pathExportInterchange,
+			},
+		},
+	}
+}
+
+// interchangeMetadata is the `metadata` object of the EIP-3076 interchange format.
+type interchangeMetadata struct {
+	InterchangeFormatVersion string `json:"interchange_format_version"`
+	GenesisValidatorsRoot    string `json:"genesis_validators_root"`
+}
+
+// interchangeSignedBlock is a single entry of a pubkey's `signed_blocks`.
+type interchangeSignedBlock struct {
+	Slot        string `json:"slot"`
+	SigningRoot string `json:"signing_root,omitempty"`
+}
+
+// interchangeSignedAttestation is a single entry of a pubkey's `signed_attestations`.
+type interchangeSignedAttestation struct {
+	SourceEpoch string `json:"source_epoch"`
+	TargetEpoch string `json:"target_epoch"`
+	SigningRoot string `json:"signing_root,omitempty"`
+}
+
+// interchangeData is a single pubkey's history within the interchange document.
+type interchangeData struct {
+	Pubkey             string                         `json:"pubkey"`
+	SignedBlocks       []interchangeSignedBlock       `json:"signed_blocks"`
+	SignedAttestations []interchangeSignedAttestation `json:"signed_attestations"`
+}
+
+// interchangeDocument is the root of the EIP-3076 interchange JSON document.
+type interchangeDocument struct {
+	Metadata interchangeMetadata `json:"metadata"`
+	Data     []interchangeData   `json:"data"`
+}
+
+// slashingHistory is the per-pubkey slashing protection bookkeeping persisted in storage. It
+// tracks the highest-water-mark values needed to reject replayed or conflicting signing
+// requests, introduced by this subsystem and consumed by every sign path added alongside it
+// (see slashingHistoryPrefix for which paths those are).
+type slashingHistory struct {
+	HighestProposedSlot uint64 `json:"highest_proposed_slot"`
+	HighestSourceEpoch  uint64 `json:"highest_source_epoch"`
+	HighestTargetEpoch  uint64 `json:"highest_target_epoch"`
+	HasProposed         bool   `json:"has_proposed"`
+	HasAttested         bool   `json:"has_attested"`
+
+	// HighestSyncCommitteeSlot bounds sync committee message signing: a signed message at
+	// slot N must not later be replayed at slot < N under a different beacon block root.
+	// Unlike HighestProposedSlot, signing again at slot N itself is allowed.
+	HighestSyncCommitteeSlot uint64 `json:"highest_sync_committee_slot"`
+	HasSignedSyncCommittee   bool   `json:"has_signed_sync_committee"`
+
+	// VoluntaryExitEpoch is the epoch of the one voluntary exit message this validator
+	// has been allowed to sign; a later request for a different epoch is rejected.
+	VoluntaryExitEpoch uint64 `json:"voluntary_exit_epoch"`
+	HasVoluntaryExit   bool   `json:"has_voluntary_exit"`
+
+	// RequiresImport is set on accounts recovered from a BIP39 seed (pathWalletRecover) and,
+	// via migrateRequireInterchangeImportGap, on every pre-existing account with no history
+	// yet in this store the first time this plugin's migrations run. Accounts created directly
+	// after that are not flagged. The operator must import an interchange file for the pubkey
+	// before it becomes signable, so neither a restored vault nor an account whose real
+	// history lives only in a store outside this plugin can accidentally double-sign.
+	// Importing any history for the pubkey (even an empty one) clears the flag.
+	RequiresImport bool `json:"requires_import,omitempty"`
+}
+
+func slashingHistoryStorageKey(pubKey string) string {
+	return slashingHistoryPrefix + pubKey
+}
+
+func getSlashingHistory(ctx context.Context, storage logical.Storage, pubKey string) (*slashingHistory, error) {
+	entry, err := storage.Get(ctx, slashingHistoryStorageKey(pubKey))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read slashing history")
+	}
+	if entry == nil {
+		return &slashingHistory{}, nil
+	}
+
+	var history slashingHistory
+	if err := entry.DecodeJSON(&history); err != nil {
+		return nil, errors.Wrap(err, "failed to decode slashing history")
+	}
+	return &history, nil
+}
+
+func putSlashingHistory(ctx context.Context, storage logical.Storage, pubKey string, history *slashingHistory) error {
+	entry, err := logical.StorageEntryJSON(slashingHistoryStorageKey(pubKey), history)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode slashing history")
+	}
+	if err := storage.Put(ctx, entry); err != nil {
+		return errors.Wrap(err, "failed to persist slashing history")
+	}
+	return nil
+}
+
+// pathImportInterchange imports an EIP-3076 interchange document, merging every pubkey's
+// history into the vault's slashing protection store using max-slot / max-epoch semantics.
+func (b *backend) pathImportInterchange(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	raw, ok := data.GetOk("interchange")
+	if !ok {
+		return nil, errorex.NewErrBadRequest("interchange document is required")
+	}
+	autoCreateMissing := data.Get("auto_create_missing").(bool)
+
+	encoded, err := json.Marshal(raw)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to re-marshal interchange document")
+	}
+
+	var doc interchangeDocument
+	if err := json.Unmarshal(encoded, &doc); err != nil {
+		return nil, errorex.NewErrBadRequest("failed to parse interchange document: " + err.Error())
+	}
+
+	if doc.Metadata.InterchangeFormatVersion != interchangeFormatVersion {
+		return nil, errorex.NewErrBadRequest("unsupported interchange_format_version " + doc.Metadata.InterchangeFormatVersion)
+	}
+
+	config, err := getConfig(ctx, req.Storage)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve plugin config")
+	}
+	gvrCheckSkipped := config == nil || config.GenesisValidatorsRoot == ""
+	if !gvrCheckSkipped && config.GenesisValidatorsRoot != doc.Metadata.GenesisValidatorsRoot {
+		return nil, errorex.NewErrBadRequest("genesis_validators_root does not match the plugin's configured GVR")
+	}
+
+	// Validate every pubkey up front so the import is all-or-nothing. merged is seeded lazily
+	// from storage the first time a pubkey is seen and reused for any later entry for the same
+	// pubkey in this document, so two `data` entries for the same pubkey merge into each other
+	// rather than the second silently overwriting the first's computed history.
+	merged := make(map[string]*slashingHistory, len(doc.Data))
+	for _, account := range doc.Data {
+		exists, err := accountExists(ctx, req.Storage, account.Pubkey)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to check account existence")
+		}
+		if !exists && !autoCreateMissing {
+			return nil, errorex.NewErrBadRequest("pubkey " + account.Pubkey + " is not present in the vault")
+		}
+
+		history, ok := merged[account.Pubkey]
+		if !ok {
+			history, err = getSlashingHistory(ctx, req.Storage, account.Pubkey)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		for _, block := range account.SignedBlocks {
+			slot, err := parseUint64(block.Slot)
+			if err != nil {
+				return nil, errorex.NewErrBadRequest("invalid slot: " + block.Slot)
+			}
+			if !history.HasProposed || slot > history.HighestProposedSlot {
+				history.HighestProposedSlot = slot
+				history.HasProposed = true
+			}
+		}
+
+		for _, att := range account.SignedAttestations {
+			source, err := parseUint64(att.SourceEpoch)
+			if err != nil {
+				return nil, errorex.NewErrBadRequest("invalid source_epoch: " + att.SourceEpoch)
+			}
+			target, err := parseUint64(att.TargetEpoch)
+			if err != nil {
+				return nil, errorex.NewErrBadRequest("invalid target_epoch: " + att.TargetEpoch)
+			}
+			if !history.HasAttested || source > history.HighestSourceEpoch {
+				history.HighestSourceEpoch = source
+			}
+			if !history.HasAttested || target > history.HighestTargetEpoch {
+				history.HighestTargetEpoch = target
+			}
+			history.HasAttested = true
+		}
+
+		history.RequiresImport = false
+		merged[account.Pubkey] = history
+	}
+
+	// All entries validated; commit them one pubkey at a time.
+	for pubKey, history := range merged {
+		if err := putSlashingHistory(ctx, req.Storage, pubKey, history); err != nil {
+			return nil, err
+		}
+	}
+
+	resp := &logical.Response{
+		Data: map[string]interface{}{
+			"imported_accounts": len(merged),
+		},
+	}
+	if gvrCheckSkipped {
+		resp.AddWarning("genesis_validators_root is not configured on this mount; the imported document's genesis_validators_root was not checked against anything, so an interchange file from the wrong network would have been accepted")
+	}
+	return resp, nil
+}
+
+// pathExportInterchange walks every account in the vault and emits the EIP-3076 interchange
+// document for it, suitable for import into another client's slashing protection database.
+func (b *backend) pathExportInterchange(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	config, err := getConfig(ctx, req.Storage)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve plugin config")
+	}
+
+	pubKeys, err := allAccountPubKeys(ctx, req.Storage)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list vault accounts")
+	}
+
+	doc := interchangeDocument{
+		Metadata: interchangeMetadata{
+			InterchangeFormatVersion: interchangeFormatVersion,
+		},
+		Data: make([]interchangeData, 0, len(pubKeys)),
+	}
+	if config != nil {
+		doc.Metadata.GenesisValidatorsRoot = config.GenesisValidatorsRoot
+	}
+
+	for _, pubKey := range pubKeys {
+		history, err := getSlashingHistory(ctx, req.Storage, pubKey)
+		if err != nil {
+			return nil, err
+		}
+
+		account := interchangeData{
+			Pubkey:             pubKey,
+			SignedBlocks:       []interchangeSignedBlock{},
+			SignedAttestations: []interchangeSignedAttestation{},
+		}
+		if history.HasProposed {
+			account.SignedBlocks = append(account.SignedBlocks, interchangeSignedBlock{
+				Slot: formatUint64(history.HighestProposedSlot),
+			})
+		}
+		if history.HasAttested {
+			account.SignedAttestations = append(account.SignedAttestations, interchangeSignedAttestation{
+				SourceEpoch: formatUint64(history.HighestSourceEpoch),
+				TargetEpoch: formatUint64(history.HighestTargetEpoch),
+			})
+		}
+		doc.Data = append(doc.Data, account)
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"metadata": doc.Metadata,
+			"data":     doc.Data,
+		},
+	}, nil
+}
+
+// pluginConfigStorageKey is the storage key the configPaths subsystem persists plugin-wide
+// configuration at, including the genesis_validators_root operators configure at setup.
+const pluginConfigStorageKey = "config"
+
+// pluginConfig is the plugin-wide configuration persisted by configPaths.
+type pluginConfig struct {
+	GenesisValidatorsRoot string `json:"genesis_validators_root"`
+
+	// Web3SignerPathPrefix is the leading path segment the Web3Signer-compatible surface is
+	// served under (defaultWeb3SignerPathPrefix unless the operator overrides it).
+	Web3SignerPathPrefix string `json:"web3signer_path_prefix"`
+	// Web3SignerEnabled opts the mount into serving the Web3Signer-compatible surface at all.
+	// Since Web3Signer clients do not carry a Vault token, authorization for every request on
+	// this surface comes from the same per-account access tokens accessTokensPaths issues
+	// (see authenticateWeb3SignerRequest and verifyRequiredAccessToken); this flag is only the
+	// master on/off switch, not a credential itself.
+	Web3SignerEnabled bool `json:"web3signer_enabled"`
+}
+
+func getConfig(ctx context.Context, storage logical.Storage) (*pluginConfig, error) {
+	entry, err := storage.Get(ctx, pluginConfigStorageKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read plugin config")
+	}
+	if entry == nil {
+		return &pluginConfig{}, nil
+	}
+
+	var config pluginConfig
+	if err := entry.DecodeJSON(&config); err != nil {
+		return nil, errors.Wrap(err, "failed to decode plugin config")
+	}
+	return &config, nil
+}
+
+// allAccountPubKeys walks every wallet in the vault and returns the hex-encoded public key
+// of every validator account, used by the interchange exporter and the Web3Signer-compatible
+// public key listing endpoint.
+func allAccountPubKeys(ctx context.Context, storage logical.Storage) ([]string, error) {
+	kv, err := core.OpenKeyVault(&core.PortfolioOptions{Storage: storage})
+	if err != nil {
+		if err == core.ErrKeyVaultNotFound {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "failed to open key vault")
+	}
+
+	wallets, err := kv.Wallets()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list wallets")
+	}
+
+	var pubKeys []string
+	for _, wallet := range wallets {
+		accounts, err := wallet.Accounts()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to list wallet accounts")
+		}
+		for _, account := range accounts {
+			pubKeys = append(pubKeys, account.ValidatorPublicKey().String())
+		}
+	}
+	return pubKeys, nil
+}
+
+// accountExists reports whether the given hex-encoded public key belongs to a validator
+// account already tracked by the vault.
+func accountExists(ctx context.Context, storage logical.Storage, pubKey string) (bool, error) {
+	pubKeys, err := allAccountPubKeys(ctx, storage)
+	if err != nil {
+		return false, err
+	}
+	for _, existing := range pubKeys {
+		if existing == pubKey {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// accountByPubKey resolves the validator account for a hex-encoded public key, searching
+// every wallet in the vault.
+func accountByPubKey(storage logical.Storage, pubKey string) (core.Account, error) {
+	kv, err := core.OpenKeyVault(&core.PortfolioOptions{Storage: storage})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open key vault")
+	}
+
+	account, err := kv.AccountByPublicKey(pubKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve account by public key")
+	}
+	return account, nil
+}
+
+func parseUint64(s string) (uint64, error) {
+	return strconv.ParseUint(s, 10, 64)
+}
+
+func formatUint64(v uint64) string {
+	return strconv.FormatUint(v, 10)
+}
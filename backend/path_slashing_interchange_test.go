@@ -0,0 +1,99 @@
+package backend
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportInterchangeMergesDuplicatePubkeyEntries(t *testing.T) {
+	b, _ := getBackend(t)
+	req := logical.TestRequest(t, logical.CreateOperation, "wallets/wallet1")
+	storage := req.Storage
+	_, err := b.HandleRequest(context.Background(), req)
+	require.NoError(t, err)
+
+	req = logical.TestRequest(t, logical.CreateOperation, "wallets/wallet1/accounts/account1")
+	req.Storage = storage
+	res, err := b.HandleRequest(context.Background(), req)
+	require.NoError(t, err)
+	pubKey := res.Data["account"].(map[string]interface{})["public_key"].(string)
+
+	t.Run("second data entry for the same pubkey merges instead of overwriting the first", func(t *testing.T) {
+		req := logical.TestRequest(t, logical.CreateOperation, "slashing-storage/interchange")
+		req.Storage = storage
+		req.Data = map[string]interface{}{
+			"interchange": map[string]interface{}{
+				"metadata": map[string]interface{}{"interchange_format_version": "5"},
+				"data": []interface{}{
+					map[string]interface{}{
+						"pubkey":              pubKey,
+						"signed_attestations": []interface{}{map[string]interface{}{"source_epoch": "1", "target_epoch": "2"}},
+					},
+					map[string]interface{}{
+						"pubkey":              pubKey,
+						"signed_attestations": []interface{}{map[string]interface{}{"source_epoch": "3", "target_epoch": "4"}},
+					},
+				},
+			},
+		}
+		_, err := b.HandleRequest(context.Background(), req)
+		require.NoError(t, err)
+
+		history, err := getSlashingHistory(context.Background(), storage, pubKey)
+		require.NoError(t, err)
+		require.True(t, history.HasAttested)
+		require.Equal(t, uint64(3), history.HighestSourceEpoch)
+		require.Equal(t, uint64(4), history.HighestTargetEpoch)
+	})
+}
+
+func TestImportInterchangeGVRCheck(t *testing.T) {
+	b, _ := getBackend(t)
+	req := logical.TestRequest(t, logical.CreateOperation, "wallets/wallet1")
+	storage := req.Storage
+	_, err := b.HandleRequest(context.Background(), req)
+	require.NoError(t, err)
+
+	req = logical.TestRequest(t, logical.CreateOperation, "wallets/wallet1/accounts/account1")
+	req.Storage = storage
+	res, err := b.HandleRequest(context.Background(), req)
+	require.NoError(t, err)
+	pubKey := res.Data["account"].(map[string]interface{})["public_key"].(string)
+
+	importReq := func() *logical.Request {
+		req := logical.TestRequest(t, logical.CreateOperation, "slashing-storage/interchange")
+		req.Storage = storage
+		req.Data = map[string]interface{}{
+			"interchange": map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"interchange_format_version": "5",
+					"genesis_validators_root":    "0xabc",
+				},
+				"data": []interface{}{
+					map[string]interface{}{"pubkey": pubKey},
+				},
+			},
+		}
+		return req
+	}
+
+	t.Run("no GVR configured warns instead of silently skipping", func(t *testing.T) {
+		res, err := b.HandleRequest(context.Background(), importReq())
+		require.NoError(t, err)
+		require.NotEmpty(t, res.Warnings)
+	})
+
+	t.Run("mismatched GVR is rejected once configured", func(t *testing.T) {
+		configReq := logical.TestRequest(t, logical.CreateOperation, "config")
+		configReq.Storage = storage
+		configReq.Data = map[string]interface{}{"genesis_validators_root": "0xdef"}
+		_, err := b.HandleRequest(context.Background(), configReq)
+		require.NoError(t, err)
+
+		_, err = b.HandleRequest(context.Background(), importReq())
+		require.EqualError(t, err, "genesis_validators_root does not match the plugin's configured GVR")
+	})
+}
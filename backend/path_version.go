@@ -25,7 +25,9 @@ func versionPaths(b *backend) []*framework.Path {
 func (b *backend) pathVersion(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
 	return &logical.Response{
 		Data: map[string]interface{}{
-			"version": b.Version,
+			"version":                b.Version,
+			"storage_schema_version": b.storageSchemaVersion,
+			"plugin_schema_version":  currentSchemaVersion,
 		},
 	}, nil
 }
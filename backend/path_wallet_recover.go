@@ -0,0 +1,147 @@
+package backend
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/bloxapp/KeyVault/core"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/pkg/errors"
+	"github.com/tyler-smith/go-bip39"
+	util "github.com/wealdtech/go-eth2-util"
+
+	"github.com/bloxapp/key-vault/utils/errorex"
+)
+
+// defaultRecoverPathTemplate is the EIP-2334 signing key derivation path used when the caller
+// does not supply one. %d is replaced with the account index.
+const defaultRecoverPathTemplate = "m/12381/3600/%d/0/0"
+
+// maxRecoverCount bounds how many accounts a single recover call derives, so a typo in count
+// cannot make the plugin churn through an unbounded derivation loop.
+const maxRecoverCount = 1000
+
+// walletRecoverPaths returns the wallet recovery path.
+func walletRecoverPaths(b *backend) []*framework.Path {
+	return []*framework.Path{
+		&framework.Path{
+			Pattern:         `wallets/(?P<name>\w(([\w-.]+)?\w)?)/recover`,
+			HelpSynopsis:    "Recovers validator accounts from a BIP39 mnemonic",
+			HelpDescription: ``,
+			Fields: map[string]*framework.FieldSchema{
+				"name":          {Type: framework.TypeString, Description: "Wallet name"},
+				"mnemonic":      {Type: framework.TypeString, Description: "BIP39 mnemonic the wallet's accounts are derived from"},
+				"passphrase":    {Type: framework.TypeString, Default: "", Description: "Optional BIP39 passphrase"},
+				"start_index":   {Type: framework.TypeInt64, Default: 0, Description: "First EIP-2334 account index to derive"},
+				"count":         {Type: framework.TypeInt64, Default: 1, Description: "Number of accounts to derive starting at start_index"},
+				"path_template": {Type: framework.TypeString, Default: defaultRecoverPathTemplate, Description: "EIP-2334 signing key derivation path template, with %d standing in for the account index"},
+			},
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.CreateOperation: b.pathWalletRecover,
+				logical.UpdateOperation: b.pathWalletRecover,
+			},
+		},
+	}
+}
+
+// recoveredAccount describes the outcome of deriving a single account index.
+type recoveredAccount struct {
+	Index     int64  `json:"index"`
+	PubKey    string `json:"pub_key"`
+	Recovered bool   `json:"recovered"`
+}
+
+// pathWalletRecover deterministically re-derives a range of validator accounts from a BIP39
+// mnemonic and EIP-2334 derivation path template, registering each one with the wallet. It is
+// idempotent: an account whose public key already exists is reported but left untouched, so
+// re-running recovery over the same seed and range is a no-op rather than a duplicate-account
+// error. A freshly recovered account has no slashing history of its own, so it is marked as
+// requiring a slashing protection interchange import before it can sign. The withdrawal
+// public key paired with each signing key is recorded in accountMeta so deposit data can
+// still be produced for the recovered validator; the withdrawal private key itself is
+// discarded rather than persisted.
+func (b *backend) pathWalletRecover(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	walletName := data.Get("name").(string)
+	mnemonic := data.Get("mnemonic").(string)
+	passphrase := data.Get("passphrase").(string)
+	startIndex := data.Get("start_index").(int64)
+	count := data.Get("count").(int64)
+	pathTemplate := data.Get("path_template").(string)
+
+	if mnemonic == "" {
+		return nil, errorex.NewErrBadRequest("mnemonic is required")
+	}
+	if !bip39.IsMnemonicValid(mnemonic) {
+		return nil, errorex.NewErrBadRequest("mnemonic is not a valid BIP39 mnemonic")
+	}
+	if startIndex < 0 {
+		return nil, errorex.NewErrBadRequest("start_index must not be negative")
+	}
+	if count < 1 || count > maxRecoverCount {
+		return nil, errorex.NewErrBadRequest(fmt.Sprintf("count must be between 1 and %d", maxRecoverCount))
+	}
+
+	seed := bip39.NewSeed(mnemonic, passphrase)
+
+	kv, err := core.OpenKeyVault(&core.PortfolioOptions{Storage: req.Storage})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open key vault")
+	}
+
+	wallet, err := kv.WalletByName(walletName)
+	if err == core.ErrWalletNotFound {
+		wallet, err = kv.CreateWallet(walletName)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve or create wallet")
+	}
+
+	accounts := make([]recoveredAccount, 0, count)
+	for i := startIndex; i < startIndex+count; i++ {
+		signingPath := fmt.Sprintf(pathTemplate, i)
+		withdrawalPath := strings.TrimSuffix(signingPath, "/0")
+
+		withdrawalSK, err := util.PrivateKeyFromSeedAndPath(seed, withdrawalPath)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to derive withdrawal key at %s", withdrawalPath)
+		}
+		signingSK, err := util.PrivateKeyFromSeedAndPath(seed, signingPath)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to derive signing key at %s", signingPath)
+		}
+		withdrawalPubKey := hex.EncodeToString(withdrawalSK.PublicKey().Marshal())
+
+		pubKey := hex.EncodeToString(signingSK.PublicKey().Marshal())
+
+		exists, err := accountExists(ctx, req.Storage, pubKey)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to check account existence")
+		}
+		if exists {
+			accounts = append(accounts, recoveredAccount{Index: i, PubKey: pubKey, Recovered: false})
+			continue
+		}
+
+		if _, err := wallet.CreateValidatorAccount(signingSK.Marshal(), &signingPath); err != nil {
+			return nil, errors.Wrapf(err, "failed to register recovered account at %s", signingPath)
+		}
+		if err := putSlashingHistory(ctx, req.Storage, pubKey, &slashingHistory{RequiresImport: true}); err != nil {
+			return nil, err
+		}
+		meta := &accountMeta{DerivationPath: signingPath, WithdrawalPublicKey: withdrawalPubKey}
+		if err := putAccountMeta(ctx, req.Storage, pubKey, meta); err != nil {
+			return nil, err
+		}
+
+		accounts = append(accounts, recoveredAccount{Index: i, PubKey: pubKey, Recovered: true})
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"accounts": accounts,
+		},
+	}, nil
+}
@@ -0,0 +1,87 @@
+package backend
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+const testRecoverMnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+func TestWalletRecover(t *testing.T) {
+	b, _ := getBackend(t)
+	req := logical.TestRequest(t, logical.CreateOperation, "wallets/wallet1")
+	storage := req.Storage
+	_, err := b.HandleRequest(context.Background(), req)
+	require.NoError(t, err)
+
+	recoverReq := func() *logical.Request {
+		req := logical.TestRequest(t, logical.CreateOperation, "wallets/wallet1/recover")
+		req.Storage = storage
+		req.Data = map[string]interface{}{
+			"mnemonic":    testRecoverMnemonic,
+			"start_index": 0,
+			"count":       1,
+		}
+		return req
+	}
+
+	var pubKey string
+	t.Run("first recovery derives and marks the account as requiring import", func(t *testing.T) {
+		res, err := b.HandleRequest(context.Background(), recoverReq())
+		require.NoError(t, err)
+		accounts := res.Data["accounts"].([]recoveredAccount)
+		require.Len(t, accounts, 1)
+		require.True(t, accounts[0].Recovered)
+		pubKey = accounts[0].PubKey
+
+		history, err := getSlashingHistory(context.Background(), storage, pubKey)
+		require.NoError(t, err)
+		require.True(t, history.RequiresImport)
+	})
+
+	t.Run("recovering the same range again is a no-op", func(t *testing.T) {
+		res, err := b.HandleRequest(context.Background(), recoverReq())
+		require.NoError(t, err)
+		accounts := res.Data["accounts"].([]recoveredAccount)
+		require.Len(t, accounts, 1)
+		require.False(t, accounts[0].Recovered)
+		require.Equal(t, pubKey, accounts[0].PubKey)
+	})
+
+	t.Run("withdrawal public key is readable after recovery", func(t *testing.T) {
+		meta, err := getAccountMeta(context.Background(), storage, pubKey)
+		require.NoError(t, err)
+		require.NotEmpty(t, meta.WithdrawalPublicKey)
+	})
+
+	t.Run("invalid mnemonic is rejected", func(t *testing.T) {
+		req := logical.TestRequest(t, logical.CreateOperation, "wallets/wallet1/recover")
+		req.Storage = storage
+		req.Data = map[string]interface{}{"mnemonic": "not a real mnemonic"}
+		_, err := b.HandleRequest(context.Background(), req)
+		require.EqualError(t, err, "mnemonic is not a valid BIP39 mnemonic")
+	})
+}
+
+func TestAccountWithdrawalPubKeyEndpoint(t *testing.T) {
+	b, _ := getBackend(t)
+	req := logical.TestRequest(t, logical.CreateOperation, "wallets/wallet1")
+	storage := req.Storage
+	_, err := b.HandleRequest(context.Background(), req)
+	require.NoError(t, err)
+
+	t.Run("a directly created account has no withdrawal public key on file", func(t *testing.T) {
+		req := logical.TestRequest(t, logical.CreateOperation, "wallets/wallet1/accounts/direct")
+		req.Storage = storage
+		_, err := b.HandleRequest(context.Background(), req)
+		require.NoError(t, err)
+
+		req = logical.TestRequest(t, logical.ReadOperation, "wallets/wallet1/accounts/direct/withdrawal-public-key")
+		req.Storage = storage
+		_, err = b.HandleRequest(context.Background(), req)
+		require.EqualError(t, err, "account direct has no withdrawal public key on file")
+	})
+}
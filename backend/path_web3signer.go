@@ -0,0 +1,370 @@
+package backend
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/pkg/errors"
+
+	"github.com/bloxapp/key-vault/utils/errorex"
+)
+
+// defaultWeb3SignerPathPrefix is used when the operator has not configured a custom prefix
+// for the Web3Signer-compatible surface via configPaths.
+const defaultWeb3SignerPathPrefix = "web3signer"
+
+// Web3Signer-compatible path patterns. The leading segment is the operator-configurable
+// prefix (defaultWeb3SignerPathPrefix unless overridden), captured rather than hard-coded
+// so it can be validated against the configured value at request time.
+const (
+	Web3SignerSignPattern       = `(?P<prefix>[^/]+)/api/v1/eth2/sign/(?P<identifier>.+)`
+	Web3SignerPublicKeysPattern = `(?P<prefix>[^/]+)/api/v1/eth2/publicKeys`
+	Web3SignerUpcheckPattern    = `(?P<prefix>[^/]+)/upcheck`
+	Web3SignerReloadPattern     = `(?P<prefix>[^/]+)/reload`
+)
+
+// web3SignerRequest is the subset of the Web3Signer sign request body this plugin needs:
+// every request type carries a `type` discriminator and, in every case this plugin supports,
+// a pre-computed `signingRoot` plus whatever slashing-protection-relevant fields the type needs.
+type web3SignerRequest struct {
+	Type        string `json:"type"`
+	SigningRoot string `json:"signingRoot"`
+	Attestation *struct {
+		Source struct {
+			Epoch string `json:"epoch"`
+		} `json:"source"`
+		Target struct {
+			Epoch string `json:"epoch"`
+		} `json:"target"`
+	} `json:"attestation"`
+	Block *struct {
+		Slot string `json:"slot"`
+	} `json:"block"`
+	VoluntaryExit *struct {
+		Epoch string `json:"epoch"`
+	} `json:"voluntary_exit"`
+	SyncCommitteeMessage *struct {
+		Slot string `json:"slot"`
+	} `json:"sync_committee_message"`
+}
+
+// web3SignerSignResponse is the Web3Signer sign response body: just the signature, no
+// envelope.
+type web3SignerSignResponse struct {
+	Signature string `json:"signature"`
+}
+
+// web3signerPaths returns the community Web3Signer-compatible REST surface, so that
+// Lighthouse, Teku and Prysm can use this plugin as a --remote-signer-url target directly.
+func web3signerPaths(b *backend) []*framework.Path {
+	return []*framework.Path{
+		&framework.Path{
+			Pattern:         Web3SignerSignPattern,
+			HelpSynopsis:    "Web3Signer-compatible eth2 sign endpoint",
+			HelpDescription: ``,
+			Fields: map[string]*framework.FieldSchema{
+				"prefix":     {Type: framework.TypeString},
+				"identifier": {Type: framework.TypeString, Description: "Hex encoded BLS public key"},
+			},
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.CreateOperation: b.pathWeb3SignerSign,
+				logical.UpdateOperation: b.pathWeb3SignerSign,
+			},
+		},
+		&framework.Path{
+			Pattern:         Web3SignerPublicKeysPattern,
+			HelpSynopsis:    "Web3Signer-compatible eth2 public key listing endpoint",
+			HelpDescription: ``,
+			Fields: map[string]*framework.FieldSchema{
+				"prefix": {Type: framework.TypeString},
+			},
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.ReadOperation: b.pathWeb3SignerPublicKeys,
+			},
+		},
+		&framework.Path{
+			Pattern:         Web3SignerUpcheckPattern,
+			HelpSynopsis:    "Web3Signer-compatible health check endpoint",
+			HelpDescription: ``,
+			Fields: map[string]*framework.FieldSchema{
+				"prefix": {Type: framework.TypeString},
+			},
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.ReadOperation: b.pathWeb3SignerUpcheck,
+			},
+		},
+		&framework.Path{
+			Pattern:         Web3SignerReloadPattern,
+			HelpSynopsis:    "Web3Signer-compatible key reload endpoint",
+			HelpDescription: ``,
+			Fields: map[string]*framework.FieldSchema{
+				"prefix": {Type: framework.TypeString},
+			},
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.UpdateOperation: b.pathWeb3SignerReload,
+			},
+		},
+	}
+}
+
+// web3SignerTypeOperations maps each Web3Signer request `type` to the access-token operation
+// that must be granted before this plugin will sign it, mirroring allowedAccessTokenOperations'
+// split between the existing sign paths. A type with no entry here is rejected outright by the
+// lookup in pathWeb3SignerSign, before any access token or slashing check.
+var web3SignerTypeOperations = map[string]string{
+	"ATTESTATION":                           "sign_attestation",
+	"BLOCK_V2":                              "sign_proposal",
+	"BLOCK":                                 "sign_proposal",
+	"VOLUNTARY_EXIT":                        "sign_exit",
+	"SYNC_COMMITTEE_MESSAGE":                "sign_sync_committee_message",
+	"SYNC_COMMITTEE_SELECTION_PROOF":        "sign_sync_committee_selection_proof",
+	"SYNC_COMMITTEE_CONTRIBUTION_AND_PROOF": "sign_contribution_and_proof",
+	"AGGREGATION_SLOT":                      "sign_aggregation",
+	"AGGREGATE_AND_PROOF":                   "sign_aggregation",
+	"RANDAO_REVEAL":                         "sign_randao_reveal",
+	"DEPOSIT":                               "sign_deposit",
+}
+
+// pathWeb3SignerSign authenticates the request (Web3Signer clients do not carry a Vault token,
+// so a per-account access token scoped to the requested pubkey and operation is this surface's
+// only gate - see verifyRequiredAccessToken), introspects the body's `type` field, applies the
+// same slashing-protection checks as the native sign paths, and returns the signature in the
+// bare `{"signature": "0x..."}` shape Web3Signer clients expect.
+func (b *backend) pathWeb3SignerSign(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	if err := b.authenticateWeb3SignerRequest(ctx, req, data); err != nil {
+		return nil, err
+	}
+
+	pubKey := data.Get("identifier").(string)
+	pubKey = strings.TrimPrefix(pubKey, "0x")
+
+	encoded, err := json.Marshal(req.Data)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to re-marshal Web3Signer request body")
+	}
+	var body web3SignerRequest
+	if err := json.Unmarshal(encoded, &body); err != nil {
+		return nil, errorex.NewErrBadRequest("failed to decode Web3Signer request body")
+	}
+
+	operation, ok := web3SignerTypeOperations[body.Type]
+	if !ok {
+		return nil, errorex.NewErrBadRequest("unsupported request type " + body.Type)
+	}
+	if err := verifyRequiredAccessToken(ctx, req.Storage, req, pubKey, operation); err != nil {
+		return nil, err
+	}
+
+	signingRoot, err := hex.DecodeString(strings.TrimPrefix(body.SigningRoot, "0x"))
+	if err != nil {
+		return nil, errorex.NewErrBadRequest("failed to decode signingRoot")
+	}
+
+	unlock := b.lockPubKey(pubKey)
+	defer unlock()
+
+	history, err := getSlashingHistory(ctx, req.Storage, pubKey)
+	if err != nil {
+		return nil, err
+	}
+	if history.RequiresImport {
+		return nil, errorex.NewErrBadRequest("account was recovered from seed and requires a slashing protection interchange import before it can sign")
+	}
+
+	switch body.Type {
+	case "ATTESTATION":
+		if body.Attestation == nil {
+			return nil, errorex.NewErrBadRequest("attestation field is required for type ATTESTATION")
+		}
+		source, err := parseUint64(body.Attestation.Source.Epoch)
+		if err != nil {
+			return nil, errorex.NewErrBadRequest("invalid source epoch")
+		}
+		target, err := parseUint64(body.Attestation.Target.Epoch)
+		if err != nil {
+			return nil, errorex.NewErrBadRequest("invalid target epoch")
+		}
+		if history.HasAttested && (source < history.HighestSourceEpoch || target <= history.HighestTargetEpoch) {
+			return nil, errorex.NewErrBadRequest("attestation violates slashing protection rules")
+		}
+		history.HighestSourceEpoch = source
+		history.HighestTargetEpoch = target
+		history.HasAttested = true
+	case "BLOCK_V2", "BLOCK":
+		if body.Block == nil {
+			return nil, errorex.NewErrBadRequest("block field is required for type " + body.Type)
+		}
+		slot, err := parseUint64(body.Block.Slot)
+		if err != nil {
+			return nil, errorex.NewErrBadRequest("invalid slot")
+		}
+		if history.HasProposed && slot <= history.HighestProposedSlot {
+			return nil, errorex.NewErrBadRequest("proposal violates slashing protection rules")
+		}
+		history.HighestProposedSlot = slot
+		history.HasProposed = true
+	case "VOLUNTARY_EXIT":
+		if body.VoluntaryExit == nil {
+			return nil, errorex.NewErrBadRequest("voluntary_exit field is required for type VOLUNTARY_EXIT")
+		}
+		epoch, err := parseUint64(body.VoluntaryExit.Epoch)
+		if err != nil {
+			return nil, errorex.NewErrBadRequest("invalid epoch")
+		}
+		if history.HasVoluntaryExit && history.VoluntaryExitEpoch != epoch {
+			return nil, errorex.NewErrBadRequest("validator already has a voluntary exit signed for a different epoch")
+		}
+		history.VoluntaryExitEpoch = epoch
+		history.HasVoluntaryExit = true
+	case "SYNC_COMMITTEE_MESSAGE":
+		if body.SyncCommitteeMessage == nil {
+			return nil, errorex.NewErrBadRequest("sync_committee_message field is required for type SYNC_COMMITTEE_MESSAGE")
+		}
+		slot, err := parseUint64(body.SyncCommitteeMessage.Slot)
+		if err != nil {
+			return nil, errorex.NewErrBadRequest("invalid slot")
+		}
+		if history.HasSignedSyncCommittee && slot < history.HighestSyncCommitteeSlot {
+			return nil, errorex.NewErrBadRequest("sync committee message violates slashing protection rules")
+		}
+		history.HighestSyncCommitteeSlot = slot
+		history.HasSignedSyncCommittee = true
+	case "AGGREGATION_SLOT", "AGGREGATE_AND_PROOF", "RANDAO_REVEAL", "SYNC_COMMITTEE_SELECTION_PROOF", "SYNC_COMMITTEE_CONTRIBUTION_AND_PROOF", "DEPOSIT":
+		// Not slashable; sign without changing the protection history.
+	default:
+		return nil, errorex.NewErrBadRequest("unsupported request type " + body.Type)
+	}
+
+	signature, err := b.signRawRoot(req.Storage, pubKey, signingRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := putSlashingHistory(ctx, req.Storage, pubKey, history); err != nil {
+		return nil, err
+	}
+
+	return web3SignerJSONResponse(http.StatusOK, web3SignerSignResponse{Signature: "0x" + signature})
+}
+
+// pathWeb3SignerPublicKeys lists every account's public key across every wallet in the vault.
+// Unlike pathWeb3SignerSign, this isn't scoped to one account, so it only requires any still
+// valid access token rather than one scoped to a specific pubkey and operation.
+func (b *backend) pathWeb3SignerPublicKeys(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	if err := b.authenticateWeb3SignerRequest(ctx, req, data); err != nil {
+		return nil, err
+	}
+	if err := verifyAnyAccessToken(ctx, req.Storage, req); err != nil {
+		return nil, err
+	}
+
+	pubKeys, err := allAccountPubKeys(ctx, req.Storage)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list vault accounts")
+	}
+
+	prefixed := make([]string, len(pubKeys))
+	for i, pubKey := range pubKeys {
+		prefixed[i] = "0x" + strings.TrimPrefix(pubKey, "0x")
+	}
+
+	return web3SignerJSONResponse(http.StatusOK, prefixed)
+}
+
+// pathWeb3SignerUpcheck reports plugin health, mirroring Web3Signer's /upcheck.
+func (b *backend) pathWeb3SignerUpcheck(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	return web3SignerJSONResponse(http.StatusOK, map[string]string{"status": "OK"})
+}
+
+// pathWeb3SignerReload is a no-op for this plugin: accounts are always read live from
+// storage, so there is no in-memory key cache to reload. Like pathWeb3SignerPublicKeys, it
+// acts across every account, so it only requires any still valid access token.
+func (b *backend) pathWeb3SignerReload(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	if err := b.authenticateWeb3SignerRequest(ctx, req, data); err != nil {
+		return nil, err
+	}
+	if err := verifyAnyAccessToken(ctx, req.Storage, req); err != nil {
+		return nil, err
+	}
+	return web3SignerJSONResponse(http.StatusOK, map[string]string{"status": "OK"})
+}
+
+// authenticateWeb3SignerRequest validates the operator-configured path prefix and that the
+// Web3Signer surface is enabled on this mount. Since Web3Signer clients do not carry a Vault
+// token, the actual authorization decision is left to the per-request access token checks
+// (verifyRequiredAccessToken / verifyAnyAccessToken) each handler performs afterward; this
+// only gates whether the surface is reachable at all.
+func (b *backend) authenticateWeb3SignerRequest(ctx context.Context, req *logical.Request, data *framework.FieldData) error {
+	config, err := getConfig(ctx, req.Storage)
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve plugin config")
+	}
+
+	prefix := data.Get("prefix").(string)
+	wantPrefix := config.Web3SignerPathPrefix
+	if wantPrefix == "" {
+		wantPrefix = defaultWeb3SignerPathPrefix
+	}
+	if prefix != wantPrefix {
+		return b.notFoundResponseErr()
+	}
+
+	if !config.Web3SignerEnabled {
+		return errorex.NewErrBadRequest("web3signer surface is not configured")
+	}
+
+	return nil
+}
+
+func (b *backend) notFoundResponseErr() error {
+	return errorex.NewErrBadRequest("unsupported path")
+}
+
+// firstHeader returns the first value of the given request header, or "" if absent. Vault
+// only populates req.Headers for headers listed in the mount's passthrough_request_headers.
+func firstHeader(headers map[string][]string, name string) string {
+	values := headers[name]
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// signRawRoot resolves the account for the requested public key and signs an already
+// domain-wrapped signing root, identical in spirit to signRoot but taking raw bytes since
+// the Web3Signer body has already been decoded.
+func (b *backend) signRawRoot(storage logical.Storage, pubKey string, signingRoot []byte) (string, error) {
+	account, err := accountByPubKey(storage, pubKey)
+	if err != nil {
+		return "", err
+	}
+
+	sig, err := account.Sign(signingRoot)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to sign root")
+	}
+
+	return hex.EncodeToString(sig.Marshal()), nil
+}
+
+// web3SignerJSONResponse writes v as the raw HTTP JSON body, bypassing Vault's usual
+// {"data": ...} envelope since Web3Signer clients expect the bare shape.
+func web3SignerJSONResponse(statusCode int, v interface{}) (*logical.Response, error) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal Web3Signer response")
+	}
+
+	return logical.RespondWithStatusCode(&logical.Response{
+		Data: map[string]interface{}{
+			logical.HTTPContentType: "application/json",
+			logical.HTTPRawBody:     body,
+			logical.HTTPStatusCode:  statusCode,
+		},
+	}, nil, statusCode)
+}
@@ -0,0 +1,84 @@
+package backend
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+func enableWeb3Signer(t *testing.T, b *backend, storage logical.Storage) {
+	req := logical.TestRequest(t, logical.CreateOperation, "config")
+	req.Storage = storage
+	req.Data = map[string]interface{}{"web3signer_enabled": true}
+	_, err := b.HandleRequest(context.Background(), req)
+	require.NoError(t, err)
+}
+
+func TestWeb3SignerUpcheckRequiresConfiguredPrefixAndEnablement(t *testing.T) {
+	b, _ := getBackend(t)
+	req := logical.TestRequest(t, logical.CreateOperation, "wallets/wallet1")
+	storage := req.Storage
+	_, err := b.HandleRequest(context.Background(), req)
+	require.NoError(t, err)
+
+	t.Run("not yet enabled is rejected", func(t *testing.T) {
+		req := logical.TestRequest(t, logical.ReadOperation, "web3signer/upcheck")
+		req.Storage = storage
+		_, err := b.HandleRequest(context.Background(), req)
+		require.EqualError(t, err, "web3signer surface is not configured")
+	})
+
+	enableWeb3Signer(t, b, storage)
+
+	t.Run("enabled with the default prefix succeeds", func(t *testing.T) {
+		req := logical.TestRequest(t, logical.ReadOperation, "web3signer/upcheck")
+		req.Storage = storage
+		res, err := b.HandleRequest(context.Background(), req)
+		require.NoError(t, err)
+		require.NotNil(t, res)
+	})
+
+	t.Run("wrong prefix is rejected", func(t *testing.T) {
+		req := logical.TestRequest(t, logical.ReadOperation, "wrong-prefix/upcheck")
+		req.Storage = storage
+		_, err := b.HandleRequest(context.Background(), req)
+		require.EqualError(t, err, "unsupported path")
+	})
+}
+
+func TestWeb3SignerPublicKeysRequiresAccessToken(t *testing.T) {
+	b, _ := getBackend(t)
+	req := logical.TestRequest(t, logical.CreateOperation, "wallets/wallet1")
+	storage := req.Storage
+	_, err := b.HandleRequest(context.Background(), req)
+	require.NoError(t, err)
+	enableWeb3Signer(t, b, storage)
+
+	req = logical.TestRequest(t, logical.CreateOperation, "wallets/wallet1/accounts/account1")
+	req.Storage = storage
+	_, err = b.HandleRequest(context.Background(), req)
+	require.NoError(t, err)
+
+	t.Run("missing access token is rejected", func(t *testing.T) {
+		req := logical.TestRequest(t, logical.ReadOperation, "web3signer/api/v1/eth2/publicKeys")
+		req.Storage = storage
+		_, err := b.HandleRequest(context.Background(), req)
+		require.EqualError(t, err, "missing access token: permission denied")
+	})
+
+	t.Run("valid access token is accepted", func(t *testing.T) {
+		tokenReq := logical.TestRequest(t, logical.CreateOperation, "wallets/wallet1/accounts/account1/access-token")
+		tokenReq.Storage = storage
+		tokenRes, err := b.HandleRequest(context.Background(), tokenReq)
+		require.NoError(t, err)
+		token := tokenRes.Data["token"].(string)
+
+		req := logical.TestRequest(t, logical.ReadOperation, "web3signer/api/v1/eth2/publicKeys")
+		req.Storage = storage
+		req.Headers = map[string][]string{"Authorization": {"Bearer " + token}}
+		_, err = b.HandleRequest(context.Background(), req)
+		require.NoError(t, err)
+	})
+}
@@ -0,0 +1,63 @@
+package keymanager
+
+import "fmt"
+
+// GenericError wraps an underlying error (if any) with a formatted message, used throughout
+// this package so callers get a consistent error shape regardless of failure source.
+type GenericError struct {
+	cause   error
+	message string
+}
+
+// NewGenericError builds a GenericError that wraps cause with a formatted message.
+func NewGenericError(cause error, format string, args ...interface{}) *GenericError {
+	return &GenericError{cause: cause, message: fmt.Sprintf(format, args...)}
+}
+
+// NewGenericErrorMessage builds a GenericError with no underlying cause.
+func NewGenericErrorMessage(format string, args ...interface{}) *GenericError {
+	return &GenericError{message: fmt.Sprintf(format, args...)}
+}
+
+// NewGenericErrorWithMessage builds a GenericError suitable for use as a predefined,
+// comparable sentinel error.
+func NewGenericErrorWithMessage(message string) *GenericError {
+	return &GenericError{message: message}
+}
+
+// Error implements the error interface.
+func (e *GenericError) Error() string {
+	if e.cause == nil {
+		return e.message
+	}
+	return fmt.Sprintf("%s: %s", e.message, e.cause.Error())
+}
+
+// Cause returns the wrapped error, if any.
+func (e *GenericError) Cause() error {
+	return e.cause
+}
+
+// HTTPRequestError is returned by sendRequest when the remote vault wallet responds with an
+// unexpected HTTP status code.
+type HTTPRequestError struct {
+	Endpoint     string
+	StatusCode   int
+	ResponseBody []byte
+	message      string
+}
+
+// NewHTTPRequestError builds an HTTPRequestError.
+func NewHTTPRequestError(endpoint string, statusCode int, responseBody []byte, message string) *HTTPRequestError {
+	return &HTTPRequestError{
+		Endpoint:     endpoint,
+		StatusCode:   statusCode,
+		ResponseBody: responseBody,
+		message:      message,
+	}
+}
+
+// Error implements the error interface.
+func (e *HTTPRequestError) Error() string {
+	return fmt.Sprintf("%s: %s returned status %d: %s", e.message, e.Endpoint, e.StatusCode, string(e.ResponseBody))
+}
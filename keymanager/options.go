@@ -0,0 +1,31 @@
+package keymanager
+
+// remoteOptsHelp documents the JSON options accepted by NewVaultRemoteHTTPWalletFromOpts.
+const remoteOptsHelp = `
+{
+  "location": "<vault address, e.g. https://vault.example.com:8200/v1/ethereum>",
+  "access_token": "<vault token or access token scoped to the wallet>",
+  "wallet_name": "<name of the wallet whose keyring this manager should serve>",
+  "pub_key": "<hex encoded public key; deprecated, kept for single-key backward compatibility>",
+  "account_name": "<name of the account access_token was issued for; required for auto-refresh>",
+  "access_token_ttl_seconds": "<lifetime to request on each renewal; access_token is left alone if omitted>"
+}
+`
+
+// remoteOpts are the options VaultRemoteHTTPWallet is constructed from.
+type remoteOpts struct {
+	Location    string `json:"location"`
+	AccessToken string `json:"access_token"`
+	WalletName  string `json:"wallet_name"`
+
+	// PubKey is deprecated: it pins the manager to a single key. Prefer WalletName, which
+	// lets the manager mirror the wallet's whole keyring via FetchValidatingKeys.
+	PubKey string `json:"pub_key"`
+
+	// AccountName and AccessTokenTTLSeconds opt the manager into auto-refreshing AccessToken
+	// via the wallets/{wallet}/accounts/{account}/access-token endpoint, at 80% of
+	// AccessTokenTTLSeconds, so a long-running validator client never signs with an expired
+	// per-account capability token. Both must be set for auto-refresh to start.
+	AccountName           string `json:"account_name"`
+	AccessTokenTTLSeconds int64  `json:"access_token_ttl_seconds"`
+}
@@ -6,6 +6,8 @@ import (
 	"encoding/json"
 	"io/ioutil"
 	"net/http"
+	"sync"
+	"time"
 
 	ethpb "github.com/prysmaticlabs/ethereumapis/eth/v1alpha1"
 	"github.com/prysmaticlabs/prysm/shared/bls"
@@ -17,11 +19,16 @@ import (
 	"github.com/bloxapp/key-vault/utils/httpex"
 )
 
+// accessTokenRefreshFraction is the point in a token's TTL, expressed as a fraction of it, at
+// which the manager renews it, so a slow renewal round trip never lets the token lapse.
+const accessTokenRefreshFraction = 0.8
+
 // Signing endpoints
 var (
-	signAggregationPath = endpoint.Build(backend.SignAggregationPattern)
-	signProposalPath    = endpoint.Build(backend.SignProposalPattern)
-	signAttestationPath = endpoint.Build(backend.SignAttestationPattern)
+	signAggregationPath      = endpoint.Build(backend.SignAggregationPattern)
+	signProposalPath         = endpoint.Build(backend.SignProposalPattern)
+	signAttestationPath      = endpoint.Build(backend.SignAttestationPattern)
+	signAttestationBatchPath = endpoint.Build(backend.SignAttestationBatchPattern)
 )
 
 // Predefined errors
@@ -30,14 +37,21 @@ var (
 	ErrNoSuchKey          = NewGenericErrorWithMessage("no such key")
 )
 
-// VaultRemoteHTTPWallet is a key manager that accesses a remote vault wallet daemon through HTTP connection.
+// VaultRemoteHTTPWallet is a key manager that accesses a remote vault wallet daemon through
+// HTTP connection. It mirrors the whole wallet's keyring rather than a single key: a
+// validator process holding thousands of keys uses one manager, not one per key.
 type VaultRemoteHTTPWallet struct {
 	remoteAddress string
-	accessToken   string
-	originPubKey  string
-	pubKey        [48]byte
+	walletName    string
+	accountName   string
 	httpClient    *http.Client
 
+	tokenMu     sync.RWMutex
+	accessToken string
+
+	mu      sync.RWMutex
+	pubKeys map[[48]byte]string // 48-byte key -> its original hex encoding
+
 	log *logrus.Entry
 }
 
@@ -55,46 +69,76 @@ func NewVaultRemoteHTTPWalletFromOpts(input string) (*VaultRemoteHTTPWallet, str
 	if len(opts.AccessToken) == 0 {
 		return nil, remoteOptsHelp, NewGenericErrorMessage("wallet access token is required")
 	}
-	if len(opts.PubKey) == 0 {
-		return nil, remoteOptsHelp, NewGenericErrorMessage("wallet public key is required")
+	if len(opts.WalletName) == 0 && len(opts.PubKey) == 0 {
+		return nil, remoteOptsHelp, NewGenericErrorMessage("either wallet_name or pub_key is required")
 	}
 
 	logger := logrus.New().WithFields(logrus.Fields{
-		"location":   opts.Location,
-		"public_key": opts.PubKey,
+		"location": opts.Location,
+		"wallet":   opts.WalletName,
 	})
 
-	decodedPubKey, err := hex.DecodeString(opts.PubKey)
-	if err != nil {
-		return nil, remoteOptsHelp, NewGenericError(err, "failed to hex decode public key '%s'", opts.PubKey)
-	}
-
-	return &VaultRemoteHTTPWallet{
+	wallet := &VaultRemoteHTTPWallet{
 		remoteAddress: opts.Location,
 		accessToken:   opts.AccessToken,
-		originPubKey:  opts.PubKey,
-		pubKey:        bytesutil.ToBytes48(decodedPubKey),
+		walletName:    opts.WalletName,
+		accountName:   opts.AccountName,
+		pubKeys:       make(map[[48]byte]string),
 		httpClient:    httpex.CreateClient(),
 		log:           logger,
-	}, remoteOptsHelp, nil
-}
+	}
 
-// NewVaultRemoteHTTPWallet is the constructor of VaultRemoteHTTPWallet.
-func NewVaultRemoteHTTPWallet(log *logrus.Entry, remoteAddress, accessToken, pubKey string) (*VaultRemoteHTTPWallet, error) {
-	// Decode public key
-	decodedPubKey, err := hex.DecodeString(pubKey)
-	if err != nil {
-		return nil, NewGenericError(err, "failed to hex decode public key '%s'", pubKey)
+	if len(opts.PubKey) > 0 {
+		if err := wallet.addPubKey(opts.PubKey); err != nil {
+			return nil, remoteOptsHelp, err
+		}
 	}
 
-	return &VaultRemoteHTTPWallet{
+	if opts.AccountName != "" && opts.AccessTokenTTLSeconds > 0 {
+		go wallet.refreshAccessTokenLoop(opts.AccessTokenTTLSeconds)
+	}
+
+	return wallet, remoteOptsHelp, nil
+}
+
+// NewVaultRemoteHTTPWallet is the constructor of VaultRemoteHTTPWallet for a single,
+// already-known public key. Kept for callers that have not migrated to wallet-wide key
+// management via FetchValidatingKeys.
+func NewVaultRemoteHTTPWallet(log *logrus.Entry, remoteAddress, accessToken, pubKey string) (*VaultRemoteHTTPWallet, error) {
+	wallet := &VaultRemoteHTTPWallet{
 		remoteAddress: remoteAddress,
 		accessToken:   accessToken,
-		originPubKey:  pubKey,
-		pubKey:        bytesutil.ToBytes48(decodedPubKey),
+		pubKeys:       make(map[[48]byte]string),
 		httpClient:    httpex.CreateClient(),
 		log:           log,
-	}, nil
+	}
+
+	if err := wallet.addPubKey(pubKey); err != nil {
+		return nil, err
+	}
+
+	return wallet, nil
+}
+
+// addPubKey hex-decodes pubKey and registers it in the manager's keyring.
+func (km *VaultRemoteHTTPWallet) addPubKey(pubKey string) error {
+	decoded, err := hex.DecodeString(pubKey)
+	if err != nil {
+		return NewGenericError(err, "failed to hex decode public key '%s'", pubKey)
+	}
+
+	km.mu.Lock()
+	defer km.mu.Unlock()
+	km.pubKeys[bytesutil.ToBytes48(decoded)] = pubKey
+	return nil
+}
+
+// originPubKey returns the original hex encoding of a known 48-byte public key.
+func (km *VaultRemoteHTTPWallet) originPubKey(pubKey [48]byte) (string, bool) {
+	km.mu.RLock()
+	defer km.mu.RUnlock()
+	origin, ok := km.pubKeys[pubKey]
+	return origin, ok
 }
 
 // Sign implements KeyManager interface.
@@ -104,13 +148,14 @@ func (km *VaultRemoteHTTPWallet) Sign(pubKey [48]byte, root [32]byte) (bls.Signa
 
 // SignGeneric implements ProtectingKeyManager interface.
 func (km *VaultRemoteHTTPWallet) SignGeneric(pubKey [48]byte, root [32]byte, domain [32]byte) (bls.Signature, error) {
-	if pubKey != km.pubKey {
+	origin, ok := km.originPubKey(pubKey)
+	if !ok {
 		return nil, ErrNoSuchKey
 	}
 
 	// Prepare request body.
 	req := VaultSignAggregationRequest{
-		PubKey:     km.originPubKey,
+		PubKey:     origin,
 		Domain:     hex.EncodeToString(domain[:]),
 		DataToSign: hex.EncodeToString(root[:]),
 	}
@@ -145,13 +190,14 @@ func (km *VaultRemoteHTTPWallet) SignGeneric(pubKey [48]byte, root [32]byte, dom
 
 // SignProposal implements ProtectingKeyManager interface.
 func (km *VaultRemoteHTTPWallet) SignProposal(pubKey [48]byte, domain [32]byte, data *ethpb.BeaconBlockHeader) (bls.Signature, error) {
-	if pubKey != km.pubKey {
+	origin, ok := km.originPubKey(pubKey)
+	if !ok {
 		return nil, ErrNoSuchKey
 	}
 
 	// Prepare request body.
 	req := VaultSignProposalRequest{
-		PubKey:        km.originPubKey,
+		PubKey:        origin,
 		Domain:        hex.EncodeToString(domain[:]),
 		Slot:          data.GetSlot(),
 		ProposerIndex: data.GetProposerIndex(),
@@ -190,13 +236,14 @@ func (km *VaultRemoteHTTPWallet) SignProposal(pubKey [48]byte, domain [32]byte,
 
 // SignAttestation implements ProtectingKeyManager interface.
 func (km *VaultRemoteHTTPWallet) SignAttestation(pubKey [48]byte, domain [32]byte, data *ethpb.AttestationData) (bls.Signature, error) {
-	if pubKey != km.pubKey {
+	origin, ok := km.originPubKey(pubKey)
+	if !ok {
 		return nil, ErrNoSuchKey
 	}
 
 	// Prepare request body.
 	req := VaultSignAttestationRequest{
-		PubKey:          km.originPubKey,
+		PubKey:          origin,
 		Domain:          hex.EncodeToString(domain[:]),
 		Slot:            data.GetSlot(),
 		CommitteeIndex:  data.GetCommitteeIndex(),
@@ -235,9 +282,108 @@ func (km *VaultRemoteHTTPWallet) SignAttestation(pubKey [48]byte, domain [32]byt
 	return sig, nil
 }
 
-// FetchValidatingKeys implements KeyManager interface.
+// FetchValidatingKeys implements KeyManager interface. It mirrors the whole wallet's
+// keyring: every account of km.walletName, streamed from the backend rather than a single
+// pinned public key.
 func (km *VaultRemoteHTTPWallet) FetchValidatingKeys() ([][48]byte, error) {
-	return [][48]byte{km.pubKey}, nil
+	if km.walletName == "" {
+		return km.knownPubKeys(), nil
+	}
+
+	path := endpoint.Build("wallets/" + km.walletName + "/accounts/pubkeys")
+
+	var resp VaultAccountsPubKeysResponse
+	if err := km.sendRequest(http.MethodGet, path, nil, &resp); err != nil {
+		km.log.WithError(err).Error("failed to fetch validating keys")
+		return nil, NewGenericError(err, "failed to fetch validating keys from remote vault wallet")
+	}
+
+	km.mu.Lock()
+	defer km.mu.Unlock()
+	for _, pubKey := range resp.Data.PubKeys {
+		decoded, err := hex.DecodeString(pubKey)
+		if err != nil {
+			return nil, NewGenericError(err, "failed to hex decode public key '%s'", pubKey)
+		}
+		km.pubKeys[bytesutil.ToBytes48(decoded)] = pubKey
+	}
+
+	keys := make([][48]byte, 0, len(km.pubKeys))
+	for key := range km.pubKeys {
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// knownPubKeys returns every public key currently registered with this manager.
+func (km *VaultRemoteHTTPWallet) knownPubKeys() [][48]byte {
+	km.mu.RLock()
+	defer km.mu.RUnlock()
+	keys := make([][48]byte, 0, len(km.pubKeys))
+	for key := range km.pubKeys {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// SignAttestationBatch signs every (pubKey, data) pair in a single round trip instead of one
+// HTTP request per key per slot, which is unworkable for a validator process holding
+// thousands of keys.
+func (km *VaultRemoteHTTPWallet) SignAttestationBatch(domain [32]byte, data map[[48]byte]*ethpb.AttestationData) (map[[48]byte]bls.Signature, error) {
+	items := make([]VaultSignAttestationBatchItem, 0, len(data))
+	order := make([][48]byte, 0, len(data))
+	for pubKey, attData := range data {
+		origin, ok := km.originPubKey(pubKey)
+		if !ok {
+			return nil, ErrNoSuchKey
+		}
+		items = append(items, VaultSignAttestationBatchItem{
+			PubKey:          origin,
+			Domain:          hex.EncodeToString(domain[:]),
+			Slot:            attData.GetSlot(),
+			CommitteeIndex:  attData.GetCommitteeIndex(),
+			BeaconBlockRoot: hex.EncodeToString(attData.GetBeaconBlockRoot()),
+			SourceEpoch:     attData.GetSource().GetEpoch(),
+			SourceRoot:      hex.EncodeToString(attData.GetSource().GetRoot()),
+			TargetEpoch:     attData.GetTarget().GetEpoch(),
+			TargetRoot:      hex.EncodeToString(attData.GetTarget().GetRoot()),
+		})
+		order = append(order, pubKey)
+	}
+
+	reqBody, err := json.Marshal(VaultSignAttestationBatchRequest{Items: items})
+	if err != nil {
+		return nil, NewGenericError(err, "failed to marshal request body")
+	}
+
+	var resp VaultSignAttestationBatchResponse
+	if err := km.sendRequest(http.MethodPost, signAttestationBatchPath, reqBody, &resp); err != nil {
+		km.log.WithError(err).Error("failed to send sign attestation batch request")
+		return nil, NewGenericError(err, "failed to send SignAttestationBatch request to remote vault wallet")
+	}
+	if len(resp.Data.Results) != len(order) {
+		return nil, NewGenericErrorMessage("batch response size mismatch: got %d, want %d", len(resp.Data.Results), len(order))
+	}
+
+	signatures := make(map[[48]byte]bls.Signature, len(order))
+	for i, result := range resp.Data.Results {
+		if result.Error != "" {
+			km.log.WithField("pub_key", order[i]).Errorf("failed to sign attestation in batch: %s", result.Error)
+			continue
+		}
+
+		decodedSignature, err := hex.DecodeString(result.Signature)
+		if err != nil {
+			return nil, NewGenericError(err, "failed to hex decode signature")
+		}
+		sig, err := bls.SignatureFromBytes(decodedSignature)
+		if err != nil {
+			return nil, NewGenericError(err, "failed to get BLS signature from bytes")
+		}
+		signatures[order[i]] = sig
+	}
+
+	return signatures, nil
 }
 
 // sendRequest implements the logic to work with HTTP requests.
@@ -251,7 +397,7 @@ func (km *VaultRemoteHTTPWallet) sendRequest(method, path string, reqBody []byte
 	}
 
 	// Pass auth token.
-	req.Header.Set("Authorization", "Bearer "+km.accessToken)
+	req.Header.Set("Authorization", "Bearer "+km.currentAccessToken())
 	req.Header.Set("Content-Type", "application/json")
 
 	// Send request.
@@ -284,3 +430,55 @@ func (km *VaultRemoteHTTPWallet) sendRequest(method, path string, reqBody []byte
 
 	return nil
 }
+
+// currentAccessToken returns the access token currently in use, safe for concurrent use with
+// refreshAccessTokenLoop swapping it out.
+func (km *VaultRemoteHTTPWallet) currentAccessToken() string {
+	km.tokenMu.RLock()
+	defer km.tokenMu.RUnlock()
+	return km.accessToken
+}
+
+func (km *VaultRemoteHTTPWallet) setAccessToken(token string) {
+	km.tokenMu.Lock()
+	defer km.tokenMu.Unlock()
+	km.accessToken = token
+}
+
+// refreshAccessTokenLoop renews the manager's access token at accessTokenRefreshFraction of
+// ttlSeconds, for as long as the process lives, so a long-running validator client never signs
+// with an expired per-account capability token.
+func (km *VaultRemoteHTTPWallet) refreshAccessTokenLoop(ttlSeconds int64) {
+	ttl := time.Duration(ttlSeconds) * time.Second
+	interval := time.Duration(float64(ttl) * accessTokenRefreshFraction)
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := km.refreshAccessToken(ttlSeconds); err != nil {
+			km.log.WithError(err).Error("failed to refresh access token")
+		}
+	}
+}
+
+// refreshAccessToken requests a new access token for km.accountName and swaps it in.
+func (km *VaultRemoteHTTPWallet) refreshAccessToken(ttlSeconds int64) error {
+	path := endpoint.Build("wallets/" + km.walletName + "/accounts/" + km.accountName + "/access-token")
+
+	reqBody, err := json.Marshal(VaultIssueAccessTokenRequest{TTLSeconds: ttlSeconds})
+	if err != nil {
+		return NewGenericError(err, "failed to marshal request body")
+	}
+
+	var resp VaultIssueAccessTokenResponse
+	if err := km.sendRequest(http.MethodPost, path, reqBody, &resp); err != nil {
+		return NewGenericError(err, "failed to send access token refresh request to remote vault wallet")
+	}
+
+	km.setAccessToken(resp.Data.Token)
+	return nil
+}
@@ -0,0 +1,57 @@
+package keymanager
+
+// VaultAccountsPubKeysResponse is the response body of the wallets/{wallet}/accounts/pubkeys
+// listing path.
+type VaultAccountsPubKeysResponse struct {
+	Data struct {
+		PubKeys []string `json:"pubkeys"`
+	} `json:"data"`
+}
+
+// VaultSignAttestationBatchItem is a single (pubkey, domain, data) tuple of a batched
+// attestation sign request.
+type VaultSignAttestationBatchItem struct {
+	PubKey          string `json:"pub_key"`
+	Domain          string `json:"domain"`
+	Slot            uint64 `json:"slot"`
+	CommitteeIndex  uint64 `json:"committee_index"`
+	BeaconBlockRoot string `json:"beacon_block_root"`
+	SourceEpoch     uint64 `json:"source_epoch"`
+	SourceRoot      string `json:"source_root"`
+	TargetEpoch     uint64 `json:"target_epoch"`
+	TargetRoot      string `json:"target_root"`
+}
+
+// VaultSignAttestationBatchRequest is the request body of the sign/attestation/batch path.
+type VaultSignAttestationBatchRequest struct {
+	Items []VaultSignAttestationBatchItem `json:"items"`
+}
+
+// VaultSignAttestationBatchResultItem is a single entry of the batch response, preserving
+// input order, with a per-item error status instead of failing the whole batch.
+type VaultSignAttestationBatchResultItem struct {
+	Signature string `json:"signature,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// VaultSignAttestationBatchResponse is the response body of the sign/attestation/batch path.
+type VaultSignAttestationBatchResponse struct {
+	Data struct {
+		Results []VaultSignAttestationBatchResultItem `json:"results"`
+	} `json:"data"`
+}
+
+// VaultIssueAccessTokenRequest is the request body of the access-token issuance/refresh path.
+// It carries no operations, so every renewal is issued with the backend's default scope.
+type VaultIssueAccessTokenRequest struct {
+	TTLSeconds int64 `json:"ttl_seconds"`
+}
+
+// VaultIssueAccessTokenResponse is the response body of the access-token issuance/refresh path.
+type VaultIssueAccessTokenResponse struct {
+	Data struct {
+		Token             string   `json:"token"`
+		ExpiresAt         int64    `json:"expires_at"`
+		AllowedOperations []string `json:"allowed_operations"`
+	} `json:"data"`
+}
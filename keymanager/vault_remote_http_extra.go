@@ -0,0 +1,152 @@
+package keymanager
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+
+	"github.com/prysmaticlabs/prysm/shared/bls"
+
+	"github.com/bloxapp/key-vault/backend"
+	"github.com/bloxapp/key-vault/utils/endpoint"
+)
+
+// Additional signing endpoints, alongside the existing attestation, proposal and
+// aggregation paths.
+var (
+	signVoluntaryExitPath               = endpoint.Build(backend.SignVoluntaryExitPattern)
+	signSyncCommitteeMessagePath        = endpoint.Build(backend.SignSyncCommitteeMessagePattern)
+	signSyncCommitteeSelectionProofPath = endpoint.Build(backend.SignSyncCommitteeSelectionProofPattern)
+	signContributionAndProofPath        = endpoint.Build(backend.SignContributionAndProofPattern)
+)
+
+// VaultSignVoluntaryExitRequest is the request body of the voluntary exit sign path.
+type VaultSignVoluntaryExitRequest struct {
+	PubKey     string `json:"pub_key"`
+	Domain     string `json:"domain"`
+	Epoch      uint64 `json:"epoch"`
+	DataToSign string `json:"data_to_sign"`
+}
+
+// VaultSignSyncCommitteeMessageRequest is the request body of the sync committee message sign path.
+type VaultSignSyncCommitteeMessageRequest struct {
+	PubKey     string `json:"pub_key"`
+	Domain     string `json:"domain"`
+	Slot       uint64 `json:"slot"`
+	DataToSign string `json:"data_to_sign"`
+}
+
+// VaultSignSyncCommitteeSelectionProofRequest is the request body of the sync committee selection proof sign path.
+type VaultSignSyncCommitteeSelectionProofRequest struct {
+	PubKey            string `json:"pub_key"`
+	Domain            string `json:"domain"`
+	Slot              uint64 `json:"slot"`
+	SubcommitteeIndex uint64 `json:"subcommittee_index"`
+	DataToSign        string `json:"data_to_sign"`
+}
+
+// VaultSignContributionAndProofRequest is the request body of the contribution and proof sign path.
+type VaultSignContributionAndProofRequest struct {
+	PubKey     string `json:"pub_key"`
+	Domain     string `json:"domain"`
+	Slot       uint64 `json:"slot"`
+	DataToSign string `json:"data_to_sign"`
+}
+
+// SignVoluntaryExit implements ProtectingKeyManager interface. Like SignGeneric, the caller
+// has already computed the domain-wrapped signing root; epoch is passed through separately
+// so the backend can enforce that a validator is never asked to sign two different exits.
+func (km *VaultRemoteHTTPWallet) SignVoluntaryExit(pubKey [48]byte, root [32]byte, domain [32]byte, epoch uint64) (bls.Signature, error) {
+	origin, ok := km.originPubKey(pubKey)
+	if !ok {
+		return nil, ErrNoSuchKey
+	}
+
+	req := VaultSignVoluntaryExitRequest{
+		PubKey:     origin,
+		Domain:     hex.EncodeToString(domain[:]),
+		Epoch:      epoch,
+		DataToSign: hex.EncodeToString(root[:]),
+	}
+
+	return km.sendSignRequest(signVoluntaryExitPath, req, "SignVoluntaryExit")
+}
+
+// SignSyncCommitteeMessage implements ProtectingKeyManager interface.
+func (km *VaultRemoteHTTPWallet) SignSyncCommitteeMessage(pubKey [48]byte, root [32]byte, domain [32]byte, slot uint64) (bls.Signature, error) {
+	origin, ok := km.originPubKey(pubKey)
+	if !ok {
+		return nil, ErrNoSuchKey
+	}
+
+	req := VaultSignSyncCommitteeMessageRequest{
+		PubKey:     origin,
+		Domain:     hex.EncodeToString(domain[:]),
+		Slot:       slot,
+		DataToSign: hex.EncodeToString(root[:]),
+	}
+
+	return km.sendSignRequest(signSyncCommitteeMessagePath, req, "SignSyncCommitteeMessage")
+}
+
+// SignSyncCommitteeSelectionProof implements ProtectingKeyManager interface.
+func (km *VaultRemoteHTTPWallet) SignSyncCommitteeSelectionProof(pubKey [48]byte, root [32]byte, domain [32]byte, slot, subcommitteeIndex uint64) (bls.Signature, error) {
+	origin, ok := km.originPubKey(pubKey)
+	if !ok {
+		return nil, ErrNoSuchKey
+	}
+
+	req := VaultSignSyncCommitteeSelectionProofRequest{
+		PubKey:            origin,
+		Domain:            hex.EncodeToString(domain[:]),
+		Slot:              slot,
+		SubcommitteeIndex: subcommitteeIndex,
+		DataToSign:        hex.EncodeToString(root[:]),
+	}
+
+	return km.sendSignRequest(signSyncCommitteeSelectionProofPath, req, "SignSyncCommitteeSelectionProof")
+}
+
+// SignContributionAndProof implements ProtectingKeyManager interface.
+func (km *VaultRemoteHTTPWallet) SignContributionAndProof(pubKey [48]byte, root [32]byte, domain [32]byte, slot uint64) (bls.Signature, error) {
+	origin, ok := km.originPubKey(pubKey)
+	if !ok {
+		return nil, ErrNoSuchKey
+	}
+
+	req := VaultSignContributionAndProofRequest{
+		PubKey:     origin,
+		Domain:     hex.EncodeToString(domain[:]),
+		Slot:       slot,
+		DataToSign: hex.EncodeToString(root[:]),
+	}
+
+	return km.sendSignRequest(signContributionAndProofPath, req, "SignContributionAndProof")
+}
+
+// sendSignRequest marshals req, POSTs it to path and decodes the resulting BLS signature.
+// It factors out the marshal-send-unmarshal-decode steps shared by every sign method.
+func (km *VaultRemoteHTTPWallet) sendSignRequest(path string, req interface{}, opName string) (bls.Signature, error) {
+	reqBody, err := json.Marshal(req)
+	if err != nil {
+		return nil, NewGenericError(err, "failed to marshal request body")
+	}
+
+	var resp VaultSignResponse
+	if err := km.sendRequest(http.MethodPost, path, reqBody, &resp); err != nil {
+		km.log.WithError(err).Errorf("failed to send %s request", opName)
+		return nil, NewGenericError(err, "failed to send %s request to remote vault wallet", opName)
+	}
+
+	decodedSignature, err := hex.DecodeString(resp.Data.Signature)
+	if err != nil {
+		return nil, NewGenericError(err, "failed to hex decode signature")
+	}
+
+	sig, err := bls.SignatureFromBytes(decodedSignature)
+	if err != nil {
+		return nil, NewGenericError(err, "failed to get BLS signature from bytes")
+	}
+
+	return sig, nil
+}